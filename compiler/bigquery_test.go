@@ -0,0 +1,38 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+)
+
+func TestCompileSafeDivision(t *testing.T) {
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "bigquery",
+			source: `prql dialect:bigquery
+from orders
+derive [average = total / quantity]`,
+			expected: `
+SELECT orders.*, SAFE_DIVIDE(total, quantity) AS average
+FROM orders`,
+		},
+		{
+			name: "generic",
+			source: `from orders
+derive [average = total / (quantity - returned)]`,
+			expected: `
+SELECT orders.*, total / NULLIF(quantity - returned, 0) AS average
+FROM orders`,
+		},
+		{
+			name: "integer division",
+			source: `prql dialect:postgres
+from orders
+derive [average = total / quantity]`,
+			expected: `
+SELECT orders.*, total::DOUBLE PRECISION / NULLIF(quantity, 0) AS average
+FROM orders`,
+		},
+	}, compiler.Options{SafeDivision: true})
+}
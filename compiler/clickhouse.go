@@ -0,0 +1,61 @@
+package compiler
+
+import (
+	"strconv"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// limitBy renders the LIMIT BY clause, with which ClickHouse keeps the first
+// rows of each group. It is only compiled for dialects with CapabilityLimitBy.
+func (g *generator) limitBy(limit *limitBy) string {
+	str := "LIMIT " + strconv.FormatInt(limit.limit, 10)
+	if limit.offset > 0 {
+		str += " OFFSET " + strconv.FormatInt(limit.offset, 10)
+	}
+	return str + " BY " + g.exprList(limit.by)
+}
+
+// strictComparison converts the operands of a comparison to types which
+// ClickHouse compares without an explicit conversion. Numbers may be compared
+// with booleans, and strings with temporal values, which are parsed from them.
+// Strings compared with numbers are converted to the type of the number, while
+// other types may not be compared at all.
+func (c *compiler) strictComparison(pos syntax.Position, left, right sqlExpr) (sqlExpr, sqlExpr, error) {
+	leftType, rightType := typeOf(left), typeOf(right)
+	if compatibleTypes(leftType, rightType) {
+		return left, right, nil
+	}
+
+	numeric := func(typ syntax.Type) bool {
+		return typ == syntax.TypeBoolean || typ == syntax.TypeInteger || typ == syntax.TypeFloat || typ == syntax.TypeScalar
+	}
+	temporal := func(typ syntax.Type) bool {
+		return typ == syntax.TypeDate || typ == syntax.TypeTime || typ == syntax.TypeTimestamp
+	}
+
+	switch {
+	case numeric(leftType) && numeric(rightType):
+	case temporal(leftType) && (temporal(rightType) || rightType == syntax.TypeString):
+	case temporal(rightType) && leftType == syntax.TypeString:
+	case numeric(leftType) && rightType == syntax.TypeString:
+		right = clickHouseConversion(right, leftType)
+	case numeric(rightType) && leftType == syntax.TypeString:
+		left = clickHouseConversion(left, rightType)
+	default:
+		return nil, nil, errorf(pos, "cannot compare %s with %s", leftType, rightType)
+	}
+	return left, right, nil
+}
+
+// clickHouseConversion converts the string into a number of the type given.
+func clickHouseConversion(str sqlExpr, typ syntax.Type) sqlExpr {
+	name := "toFloat64"
+	switch typ {
+	case syntax.TypeInteger:
+		name = "toInt64"
+	case syntax.TypeBoolean:
+		name = "toBool"
+	}
+	return funcExpr{name: name, args: []sqlExpr{str}, typ: typ}
+}
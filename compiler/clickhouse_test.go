@@ -0,0 +1,56 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileClickHouseComparisons(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "compatible types",
+			source: `prql dialect:clickhouse
+from t
+derive [n = text.length name]
+filter (n > 1.5 and created > "2024-01-01" and ok == true)`,
+			expected: `
+SELECT t.*, lengthUTF8(name) AS n
+FROM t
+WHERE lengthUTF8(name) > 1.5 AND created > '2024-01-01' AND ok = TRUE`,
+		},
+		{
+			name: "strings compared with numbers are converted",
+			source: `prql dialect:clickhouse
+from t
+derive [n = text.length name]
+filter (n == "5" and true != "false")`,
+			expected: `
+SELECT t.*, lengthUTF8(name) AS n
+FROM t
+WHERE lengthUTF8(name) = toInt64('5') AND TRUE <> toBool('false')`,
+		},
+		{
+			name: "aggregates are named in camel case",
+			source: `prql dialect:clickhouse
+from t
+group a (aggregate [c = count, d = count x, lo = min x, hi = max x, s = sum x])`,
+			expected: `
+SELECT a, count() AS c, count(x) AS d, min(x) AS lo, max(x) AS hi, sum(x) AS s
+FROM t
+GROUP BY a`,
+		},
+		{
+			name: "open take falls back to a window",
+			source: `prql dialect:clickhouse
+from t
+select [a, b]
+group a (take 2..)`,
+			expected: `
+SELECT a, b
+FROM (
+  SELECT a, b, ROW_NUMBER() OVER (PARTITION BY a) AS _expr_0
+  FROM t
+) AS table_0
+WHERE _expr_0 >= 2`,
+		},
+	})
+
+	compileError(t, "prql dialect:clickhouse\nfrom t\nfilter @2024-01-01 > 5", "3:8: cannot compare date with integer")
+}
@@ -0,0 +1,138 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+)
+
+// compileTest holds a PRQL source and the SQL it is expected to compile into
+type compileTest struct {
+	name     string
+	source   string
+	expected string
+}
+
+// compile parses and compiles the source with the default options, failing the
+// test on any errors.
+func compile(t *testing.T, source string) string {
+	t.Helper()
+	return compileWith(t, source, compiler.Options{})
+}
+
+// compileWith parses and compiles the source with the given options, failing
+// the test on any errors.
+func compileWith(t *testing.T, source string, opts compiler.Options) string {
+	t.Helper()
+
+	query, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	sql, err := compiler.Compile(query, opts)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %s", err)
+	}
+	return sql
+}
+
+// compileError parses and compiles the source with the default options,
+// expecting a compile error containing the given message.
+func compileError(t *testing.T, source string, message string) {
+	t.Helper()
+	compileErrorWith(t, source, compiler.Options{}, message)
+}
+
+// compileErrorWith parses and compiles the source with the given options,
+// expecting a compile error containing the given message.
+func compileErrorWith(t *testing.T, source string, opts compiler.Options, message string) {
+	t.Helper()
+
+	query, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	_, err = compiler.Compile(query, opts)
+	if err == nil {
+		t.Fatalf("expected compile error containing %q", message)
+	}
+	if !strings.Contains(err.Error(), message) {
+		t.Errorf("expected compile error containing %q, received %q", message, err.Error())
+	}
+}
+
+func runCompileTests(t *testing.T, tests []compileTest) {
+	t.Helper()
+	runCompileTestsWith(t, tests, compiler.Options{})
+}
+
+func runCompileTestsWith(t *testing.T, tests []compileTest, opts compiler.Options) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql := compileWith(t, test.source, opts)
+			expected := strings.TrimSpace(test.expected)
+			if sql != expected {
+				t.Errorf("unexpected SQL\nexpected:\n%s\nreceived:\n%s", expected, sql)
+			}
+		})
+	}
+}
+
+func TestCompilePipeline(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name:     "from",
+			source:   `from employees`,
+			expected: "SELECT *\nFROM employees",
+		},
+		{
+			name: "derive inlines names",
+			source: `
+from e = employees
+filter country_code == "USA"
+derive [
+  gross_salary = salary + payroll_tax,
+  gross_cost = gross_salary + benefits_cost,
+]
+select [name, gross_cost]`,
+			expected: `
+SELECT name, salary + payroll_tax + benefits_cost AS gross_cost
+FROM employees AS e
+WHERE country_code = 'USA'`,
+		},
+		{
+			name: "filter after aggregate",
+			source: `
+from employees
+aggregate [ct = count]
+filter ct > 10`,
+			expected: `
+SELECT COUNT(*) AS ct
+FROM employees
+HAVING COUNT(*) > 10`,
+		},
+		{
+			name: "calls without brackets",
+			source: `
+from employees
+filter text.starts_with "A" name
+aggregate total = sum salary`,
+			expected: `
+SELECT SUM(salary) AS total
+FROM employees
+WHERE name LIKE 'A%'`,
+		},
+	})
+}
+
+func TestCompileErrors(t *testing.T) {
+	compileError(t, "from employees\nselect [name]\nderive x = salary", "3:12: unknown name 'salary'")
+	compileError(t, "from employees\nexplode x", "unknown transform 'explode'")
+	compileError(t, "from employees\nderive x = (sum a b)", "function 'sum' expects 1 arguments but received 2")
+}
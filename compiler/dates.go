@@ -0,0 +1,294 @@
+package compiler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// addInterval adds the interval to the date, or subtracts it when negate is
+// true, for the dialects without interval arithmetic. Returns false when the
+// dialect adds intervals with the "+" and "-" operators.
+func (c *compiler) addInterval(date sqlExpr, interval intervalExpr, negate bool) (sqlExpr, bool) {
+	template := c.target.DateAdd(interval.value, strings.TrimSuffix(interval.unit, "s"), negate, typeOf(date))
+	if template == "" {
+		return nil, false
+	}
+	return funcExpr{template: template, args: []sqlExpr{date}, typ: typeOf(date)}, true
+}
+
+// DateAdd implements Dialect.
+//
+// MSSQL and Snowflake use "DATEADD(unit, value, date)", and MySQL "DATE_ADD(date, INTERVAL
+// value UNIT)" or "DATE_SUB". MySQL has no unit of milliseconds, so they are
+// added as microseconds.
+//
+// Hive adds days with "DATE_ADD(date, days)" or "DATE_SUB", and months with
+// "ADD_MONTHS(date, months)", which return dates, so uses interval arithmetic
+// for timestamps and smaller units.
+//
+// SQLite adds a modifier with the function of the date's type, such as
+// "DATETIME(date, '+7 days')".
+func (d builtinDialect) DateAdd(value, unit string, negate bool, typ syntax.Type) string {
+	switch d.dialect {
+	case syntax.DialectSQLite:
+		fn, ok := sqliteDateFunctions[typ]
+		if !ok {
+			fn = "DATETIME"
+		}
+		return fn + "({0}, '" + sqliteModifier(value, unit, negate) + "')"
+	case syntax.DialectMSSQL, syntax.DialectSnowflake:
+		return dateAdd(value, unit, negate)
+	case syntax.DialectHive:
+		if typ == syntax.TypeTimestamp {
+			return ""
+		}
+		if unit == "day" || unit == "week" {
+			fn := "DATE_ADD"
+			if negate {
+				fn = "DATE_SUB"
+			}
+			if unit == "week" {
+				value = scaleInterval(value, 7)
+			}
+			return fn + "({0}, " + value + ")"
+		}
+		months, ok := hiveMonths[unit]
+		if !ok {
+			return ""
+		}
+		if negate {
+			months = -months
+		}
+		return "ADD_MONTHS({0}, " + scaleInterval(value, months) + ")"
+	case syntax.DialectMYSQL:
+		fn := "DATE_ADD"
+		if negate {
+			fn = "DATE_SUB"
+		}
+		if unit == "millisecond" {
+			value, unit = value+" * 1000", "microsecond"
+		}
+		return fn + "({0}, INTERVAL " + value + " " + strings.ToUpper(unit) + ")"
+	}
+	return ""
+}
+
+// Interval implements Dialect. Hive has no unit of weeks, so they are given
+// as days. Postgres has no qualifier for weeks or units smaller than seconds,
+// so gives the unit within the quoted text instead, as "INTERVAL '2 weeks'".
+func (d builtinDialect) Interval(value, unit string) string {
+	switch d.dialect {
+	case syntax.DialectHive:
+		if unit == "week" {
+			value, unit = scaleInterval(value, 7), "day"
+		}
+	case syntax.DialectPostgres:
+		if value != "1" && value != "-1" {
+			unit += "s"
+		}
+		return "INTERVAL '" + value + " " + unit + "'"
+	}
+	return "INTERVAL '" + value + "' " + strings.ToUpper(unit)
+}
+
+// hiveMonths holds the number of months of each interval unit added with
+// "ADD_MONTHS" by Hive
+var hiveMonths = map[string]int{"month": 1, "quarter": 3, "year": 12}
+
+// scaleInterval multiplies the value of an interval.
+func scaleInterval(value string, scale int) string {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return value + " * " + strconv.Itoa(scale)
+	}
+	return strconv.FormatInt(n*int64(scale), 10)
+}
+
+// sqliteUnits holds the SQLite modifier and multiplier of each interval unit
+// which SQLite does not have
+var sqliteUnits = map[string]struct {
+	unit  string
+	scale float64
+}{
+	"week":        {"days", 7},
+	"millisecond": {"seconds", 0.001},
+	"microsecond": {"seconds", 0.000001},
+}
+
+// sqliteModifier returns the SQLite date modifier adding the interval, such as
+// "+7 days".
+func sqliteModifier(value, unit string, negate bool) string {
+	if scaled, ok := sqliteUnits[unit]; ok {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			value = strconv.FormatFloat(n*scaled.scale, 'f', -1, 64)
+		}
+		unit = scaled.unit
+	} else {
+		unit += "s"
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	if negative != negate {
+		return "-" + value + " " + unit
+	}
+	return "+" + value + " " + unit
+}
+
+// dateUnits holds the units accepted by the date functions, from largest to
+// smallest
+var dateUnits = []string{"year", "quarter", "month", "week", "day", "hour", "minute", "second"}
+
+// dateUnit returns the unit given as a string literal argument, such as the
+// "month" of `date.trunc "month" created`.
+func dateUnit(pos syntax.Position, name string, arg sqlExpr) (string, error) {
+	if lit, ok := arg.(literalExpr); ok && lit.typ == syntax.TypeString {
+		for _, unit := range dateUnits {
+			if lit.value == unit {
+				return unit, nil
+			}
+		}
+	}
+	return "", errorf(pos, "function '%s' expects a unit of %s", name, strings.Join(dateUnits, ", "))
+}
+
+// dateFormat describes how a dialect formats dates as text.
+type dateFormat struct {
+	// specifiers maps the strftime specifiers used within PRQL, such as the
+	// "Y" of "%Y", to those of the dialect
+	specifiers map[byte]string
+
+	// literal escapes the text between specifiers
+	literal func(string) string
+}
+
+// percentLiteral escapes text for the formats using "%" specifiers.
+func percentLiteral(text string) string {
+	return strings.ReplaceAll(text, "%", "%%")
+}
+
+// quotedLiteral returns a function escaping text which holds any letters, by
+// quoting it.
+func quotedLiteral(quote string) func(string) string {
+	return func(text string) string {
+		if strings.IndexFunc(text, isLetter) < 0 {
+			return text
+		}
+		return quote + strings.ReplaceAll(text, quote, quote+quote) + quote
+	}
+}
+
+func isLetter(char rune) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// strftimeFormat is the C style of format, which PRQL also uses
+var strftimeFormat = dateFormat{
+	specifiers: map[byte]string{
+		'Y': "%Y", 'y': "%y", 'm': "%m", 'd': "%d", 'H': "%H", 'M': "%M", 'S': "%S", 'I': "%I",
+		'p': "%p", 'B': "%B", 'b': "%b", 'A': "%A", 'a': "%a", 'j': "%j",
+	},
+	literal: percentLiteral,
+}
+
+// toCharFormat is used by the "TO_CHAR" function of Postgres and others
+var toCharFormat = dateFormat{
+	specifiers: map[byte]string{
+		'Y': "YYYY", 'y': "YY", 'm': "MM", 'd': "DD", 'H': "HH24", 'M': "MI", 'S': "SS", 'I': "HH12",
+		'p': "AM", 'B': "FMMonth", 'b': "Mon", 'A': "FMDay", 'a': "Dy", 'j': "DDD",
+	},
+	literal: quotedLiteral(`"`),
+}
+
+// dateFormats holds the formats which differ from TO_CHAR, by dialect
+var dateFormats = map[syntax.Dialect]dateFormat{
+	syntax.DialectBigQuery: strftimeFormat,
+	syntax.DialectClickHouse: {
+		specifiers: map[byte]string{
+			'Y': "%Y", 'y': "%y", 'm': "%m", 'd': "%d", 'H': "%H", 'M': "%i", 'S': "%S", 'I': "%I",
+			'p': "%p", 'B': "%B", 'b': "%b", 'A': "%W", 'a': "%a", 'j': "%j",
+		},
+		literal: percentLiteral,
+	},
+	// Hive quotes text within the pattern, which must reach it unchanged, so
+	// the quotes are escaped by the string literal of the pattern rather than
+	// doubled, which Hive would read as adjacent literals to concatenate
+	syntax.DialectHive: {
+		specifiers: map[byte]string{
+			'Y': "yyyy", 'y': "yy", 'm': "MM", 'd': "dd", 'H': "HH", 'M': "mm", 'S': "ss", 'I': "hh",
+			'p': "a", 'B': "MMMM", 'b': "MMM", 'A': "EEEE", 'a': "EEE", 'j': "DDD",
+		},
+		literal: quotedLiteral("'"),
+	},
+	syntax.DialectMSSQL: {
+		specifiers: map[byte]string{
+			'Y': "yyyy", 'y': "yy", 'm': "MM", 'd': "dd", 'H': "HH", 'M': "mm", 'S': "ss", 'I': "hh",
+			'p': "tt", 'B': "MMMM", 'b': "MMM", 'A': "dddd", 'a': "ddd",
+		},
+		literal: quotedLiteral(`"`),
+	},
+	syntax.DialectMYSQL: {
+		specifiers: map[byte]string{
+			'Y': "%Y", 'y': "%y", 'm': "%m", 'd': "%d", 'H': "%H", 'M': "%i", 'S': "%s", 'I': "%h",
+			'p': "%p", 'B': "%M", 'b': "%b", 'A': "%W", 'a': "%a", 'j': "%j",
+		},
+		literal: percentLiteral,
+	},
+	syntax.DialectSnowflake: {
+		specifiers: map[byte]string{
+			'Y': "YYYY", 'y': "YY", 'm': "MM", 'd': "DD", 'H': "HH24", 'M': "MI", 'S': "SS", 'I': "HH12",
+			'p': "AM", 'B': "MMMM", 'b': "MON", 'a': "DY",
+		},
+		literal: quotedLiteral(`"`),
+	},
+	syntax.DialectSQLite: {
+		specifiers: map[byte]string{
+			'Y': "%Y", 'm': "%m", 'd': "%d", 'H': "%H", 'M': "%M", 'S': "%S", 'j': "%j",
+		},
+		literal: percentLiteral,
+	},
+}
+
+// DateFormat implements Dialect using the style of dateFormats, or that of
+// TO_CHAR.
+func (d builtinDialect) DateFormat(format string) (string, string) {
+	style, found := dateFormats[d.dialect]
+	if !found {
+		style = toCharFormat
+	}
+	return style.translate(format)
+}
+
+// translate converts a strftime format, as used by PRQL, into the style.
+// Returns the specifier which the style cannot format as unknown.
+func (style dateFormat) translate(format string) (translated, unknown string) {
+	var str, text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			str.WriteString(style.literal(text.String()))
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			text.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		if format[i] == '%' {
+			text.WriteByte('%')
+			continue
+		}
+		specifier, found := style.specifiers[format[i]]
+		if !found {
+			return "", "%" + string(format[i])
+		}
+		flush()
+		str.WriteString(specifier)
+	}
+	flush()
+	return str.String(), ""
+}
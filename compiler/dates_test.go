@@ -0,0 +1,115 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileDateFunctions(t *testing.T) {
+	const pipeline = `
+from t
+derive [
+  m = date.trunc "month" created,
+  w = date.trunc "week" created,
+  y = date.extract "year" created,
+  s = (created | date.to_text "%d/%m/%Y at %H:%M"),
+]`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "postgres",
+			source: "prql dialect:postgres" + pipeline,
+			expected: `
+SELECT t.*, DATE_TRUNC('month', created) AS m, DATE_TRUNC('week', created) AS w, EXTRACT(YEAR FROM created) AS y, TO_CHAR(created, 'DD/MM/YYYY" at "HH24:MI') AS s
+FROM t`,
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql" + pipeline,
+			expected: `
+SELECT t.*, DATETRUNC(month, created) AS m, DATETRUNC(iso_week, created) AS w, DATEPART(year, created) AS y, FORMAT(created, 'dd/MM/yyyy" at "HH:mm') AS s
+FROM t`,
+		},
+		{
+			name:   "mysql",
+			source: "prql dialect:mysql" + pipeline,
+			expected: `
+SELECT t.*, CAST(DATE_FORMAT(created, '%Y-%m-01') AS DATE) AS m, DATE_SUB(DATE(created), INTERVAL WEEKDAY(created) DAY) AS w, EXTRACT(YEAR FROM created) AS y, DATE_FORMAT(created, '%d/%m/%Y at %H:%i') AS s
+FROM t`,
+		},
+		{
+			name:   "sqlite",
+			source: "prql dialect:sqlite" + pipeline,
+			expected: `
+SELECT t.*, DATE(created, 'start of month') AS m, DATE(created, '-6 days', 'weekday 1') AS w, CAST(STRFTIME('%Y', created) AS INTEGER) AS y, STRFTIME('%d/%m/%Y at %H:%M', created) AS s
+FROM t`,
+		},
+		{
+			name:   "bigquery",
+			source: "prql dialect:bigquery" + pipeline,
+			expected: `
+SELECT t.*, DATE_TRUNC(created, MONTH) AS m, DATE_TRUNC(created, ISOWEEK) AS w, EXTRACT(YEAR FROM created) AS y, FORMAT_TIMESTAMP('%d/%m/%Y at %H:%M', created) AS s
+FROM t`,
+		},
+		{
+			name:   "hive",
+			source: "prql dialect:hive\nfrom t\nderive [m = date.trunc \"month\" created, s = (created | date.to_text \"%d/%m/%Y at %H:%M\")]",
+			expected: `
+SELECT t.*, TRUNC(created, 'MONTH') AS m, DATE_FORMAT(created, 'dd/MM/yyyy\' at \'HH:mm') AS s
+FROM t`,
+		},
+		{
+			name:   "clickhouse",
+			source: "prql dialect:clickhouse" + pipeline,
+			expected: `
+SELECT t.*, toStartOfMonth(created) AS m, toMonday(created) AS w, toYear(created) AS y, formatDateTime(created, '%d/%m/%Y at %H:%i') AS s
+FROM t`,
+		},
+	})
+}
+
+func TestCompileDateDiff(t *testing.T) {
+	const pipeline = `
+from t
+derive [d = date.diff "day" opened closed]`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:     "mssql",
+			source:   "prql dialect:mssql" + pipeline,
+			expected: "SELECT t.*, DATEDIFF(day, opened, closed) AS d\nFROM t",
+		},
+		{
+			name:     "snowflake",
+			source:   "prql dialect:snowflake" + pipeline,
+			expected: "SELECT t.*, DATEDIFF(day, opened, closed) AS d\nFROM t",
+		},
+		{
+			name:     "postgres",
+			source:   "prql dialect:postgres" + pipeline,
+			expected: "SELECT t.*, closed::DATE - opened::DATE AS d\nFROM t",
+		},
+		{
+			name:     "mysql",
+			source:   "prql dialect:mysql" + pipeline,
+			expected: "SELECT t.*, TIMESTAMPDIFF(DAY, opened, closed) AS d\nFROM t",
+		},
+		{
+			name:     "bigquery",
+			source:   "prql dialect:bigquery" + pipeline,
+			expected: "SELECT t.*, DATE_DIFF(closed, opened, DAY) AS d\nFROM t",
+		},
+		{
+			name:     "sqlite",
+			source:   "prql dialect:sqlite" + pipeline,
+			expected: "SELECT t.*, CAST(JULIANDAY(closed) - JULIANDAY(opened) AS INTEGER) AS d\nFROM t",
+		},
+	})
+}
+
+func TestCompileDateFunctionErrors(t *testing.T) {
+	compileError(t, "from t\nderive [x = date.trunc \"fortnight\" created]", "2:13: function 'date.trunc' expects a unit of year, quarter, month, week, day, hour, minute, second")
+	compileError(t, "from t\nderive [x = date.to_text f created]", "function 'date.to_text' expects the format to be a string literal")
+	compileError(t, "from t\nderive [x = date.extract \"day\" 5]", "function 'date.extract' expects argument 2 to be a date or time but found integer")
+	compileError(t, "prql dialect:sqlite\nfrom t\nderive [x = date.to_text \"%B\" created]", "date format '%B' in 'derive' is not supported by dialect sqlite")
+	compileError(t, "prql dialect:hive\nfrom t\nderive [x = date.trunc \"hour\" created]", "function 'date.trunc' by hour in 'derive' is not supported by dialect hive")
+	compileError(t, "prql dialect:postgres\nfrom t\nderive [x = date.diff \"month\" a b]", "function 'date.diff' by month in 'derive' is not supported by dialect postgres")
+	compileError(t, "from t\nderive [x = date.diff \"day\" a b]", "function 'date.diff' by day in 'derive' is not supported by dialect generic")
+}
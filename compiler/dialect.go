@@ -0,0 +1,703 @@
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// Dialect generates the SQL which differs between databases. Each of the
+// syntax.Dialect values declared by the syntax package has a built-in Dialect,
+// and others are added with RegisterDialect.
+//
+// Only the SQL of the hooks below, and the capabilities they support, may be
+// changed by other dialects. Those embedding the Dialect of BuiltinDialect
+// keep the SQL of it's hooks which they do not replace.
+type Dialect interface {
+	// QuoteIdent renders a single identifier, quoting it if required.
+	QuoteIdent(name string) string
+
+	// Literal renders a constant value of the type as written in PRQL, other
+	// than nulls and booleans.
+	Literal(typ syntax.Type, value string) string
+
+	// Boolean renders a boolean constant.
+	Boolean(value bool) string
+
+	// Limit renders the clauses limiting the rows of a query, where a negative
+	// limit keeps every row after the offset. The top is placed after the
+	// "SELECT", and the clauses follow the ORDER BY, which ordered is true for.
+	Limit(limit, offset int64, ordered bool) (top string, clauses []string)
+
+	// Concat renders the concatenation of the already rendered strings.
+	Concat(parts []string) string
+
+	// Function returns the SQL template of a standard function by it's PRQL
+	// name, and date unit for the functions of one. The template uses "{0}",
+	// "{1}" and so on for the arguments, and "{unit}" or "{UNIT}" for the unit.
+	// Returns false to use the SQL of the generic dialect, or an empty
+	// template if the function is not supported.
+	//
+	// The operations which differ between databases are also given by name:
+	// "~=" matching text against a regular expression, "<=>" comparing values
+	// where nulls equal each other, and "case" choosing between two values by
+	// a condition, given as the arguments condition, value and default.
+	Function(name, unit string) (string, bool)
+
+	// Cast returns the SQL template converting the value "{0}" into the type.
+	Cast(typ syntax.Type) string
+
+	// Interval renders a duration of the value and unit, such as "3" and
+	// "day", for adding to dates with the "+" and "-" operators.
+	Interval(value, unit string) string
+
+	// DateAdd returns the SQL template adding a duration of the value and unit
+	// to the date "{0}" of the type, or subtracting it when negate is true.
+	// Returns an empty template to use the "+" and "-" operators with an
+	// Interval.
+	DateAdd(value, unit string, negate bool, typ syntax.Type) string
+
+	// DateFormat converts a strftime format, as used by PRQL, into the format
+	// of the dialect's function for "date.to_text". Returns the specifier
+	// which the dialect cannot format as unknown.
+	DateFormat(format string) (translated, unknown string)
+
+	// Supports returns true if the database has the capability.
+	Supports(capability Capability) bool
+}
+
+// Capability is a byte enum of the features which databases may not have.
+// Where one is missing, the compiler either emulates it or returns an
+// UnsupportedError.
+type Capability byte
+
+const (
+	// CapabilityFullJoin is "FULL JOIN"
+	CapabilityFullJoin Capability = iota
+
+	// CapabilityRecursiveTables is recursive common tables, used by "loop"
+	CapabilityRecursiveTables
+
+	// CapabilityIntersectExcept is the "INTERSECT" and "EXCEPT" set operators
+	CapabilityIntersectExcept
+
+	// CapabilityNestedSetOperations is parenthesised queries as the operands
+	// of set operations, needed when an operand is ordered or limited
+	CapabilityNestedSetOperations
+
+	// CapabilityDistinctOn is "SELECT DISTINCT ON (...)"
+	CapabilityDistinctOn
+
+	// CapabilityNullsOrder is "NULLS FIRST" and "NULLS LAST" within an ORDER BY
+	CapabilityNullsOrder
+
+	// CapabilityILike is the case insensitive "ILIKE" operator
+	CapabilityILike
+
+	// CapabilityAggregateFilter is "FILTER (WHERE ...)" following an aggregate
+	// function, which otherwise is emulated with a CASE expression
+	CapabilityAggregateFilter
+
+	// CapabilitySeries is the "GENERATE_SERIES(start, end)" table function,
+	// used by "from" with a range
+	CapabilitySeries
+
+	// CapabilityBooleans is boolean values, such as a condition within the
+	// SELECT list. Otherwise booleans are the integers 1 and 0, and conditions
+	// are converted with CASE expressions.
+	CapabilityBooleans
+
+	// CapabilityWindows is window functions, and aggregates over a window
+	CapabilityWindows
+
+	// CapabilityCommonTables is the common tables of a WITH clause, used by
+	// declared tables
+	CapabilityCommonTables
+
+	// CapabilityQualify is the "QUALIFY" clause filtering the results of window
+	// functions, which otherwise requires a subquery
+	CapabilityQualify
+
+	// CapabilityStarExcept is "* EXCEPT (...)", or "* EXCLUDE (...)" with
+	// CapabilityStarExclude, selecting all but some columns of a relation
+	CapabilityStarExcept
+
+	// CapabilityLimitBy is "LIMIT n BY ...", keeping the first rows of each
+	// group without a window function
+	CapabilityLimitBy
+
+	// CapabilityRightJoin is "RIGHT JOIN", which otherwise is emulated by a
+	// LEFT JOIN of the relations swapped
+	CapabilityRightJoin
+
+	// CapabilityOffset is skipping the first rows with "OFFSET", which
+	// otherwise is emulated by filtering on their row numbers
+	CapabilityOffset
+
+	// CapabilityWindowFrames is the frame of a window bounded by offsets from
+	// the current row's value, "RANGE BETWEEN 5 PRECEDING AND CURRENT ROW",
+	// rather than only a number of rows
+	CapabilityWindowFrames
+
+	// CapabilityRegex is matching text against a regular expression, used by
+	// the "~=" operator
+	CapabilityRegex
+
+	// CapabilityJoinUsing is "JOIN ... USING (...)", which otherwise is
+	// emulated by comparing the columns of both relations with ON
+	CapabilityJoinUsing
+
+	// CapabilityFloatDivision is dividing integers into a fraction, which
+	// otherwise requires the dividend be cast into a float
+	CapabilityFloatDivision
+
+	// CapabilitySafeDivide is the "SAFE_DIVIDE(a, b)" function returning null
+	// when dividing by zero, which otherwise uses "a / NULLIF(b, 0)"
+	CapabilitySafeDivide
+
+	// CapabilityImplicitConversions is converting the values of a comparison
+	// into the same type, such as a string compared with a number. Otherwise
+	// the conversions are explicit.
+	CapabilityImplicitConversions
+
+	// CapabilityRecursiveKeyword is "WITH RECURSIVE" declaring recursive
+	// common tables. Otherwise any common table may be recursive.
+	CapabilityRecursiveKeyword
+
+	// CapabilityImplicitDistinct is the set operators keeping distinct rows
+	// unless they are followed by "ALL", rather than requiring "DISTINCT"
+	CapabilityImplicitDistinct
+
+	// CapabilityBareQualify is "QUALIFY" within a query which neither filters
+	// nor groups it's rows, which otherwise needs a "WHERE TRUE"
+	CapabilityBareQualify
+
+	// CapabilityPathQualifiers is qualifying columns with the whole path of
+	// their table, as in "dataset.orders.id". Otherwise columns are qualified
+	// by the last part of the path, and quoted paths are quoted whole.
+	CapabilityPathQualifiers
+
+	// CapabilityStarExclude is "* EXCLUDE (...)" in place of "* EXCEPT (...)"
+	CapabilityStarExclude
+
+	// CapabilityLikeBackslash is a backslash escaping the characters of a LIKE
+	// pattern, which otherwise is declared with "ESCAPE"
+	CapabilityLikeBackslash
+
+	// CapabilityLikeSets is matching any of a set of characters within square
+	// brackets in a LIKE pattern, so "[" is escaped
+	CapabilityLikeSets
+
+	// CapabilityCaseSensitiveLike is "LIKE" matching the case of the text,
+	// which otherwise is only used for matches ignoring case
+	CapabilityCaseSensitiveLike
+)
+
+// holds Capability -> string mapping
+var capabilityStringMap = map[Capability]string{
+	CapabilityFullJoin:            "full_join",
+	CapabilityRecursiveTables:     "recursive_tables",
+	CapabilityIntersectExcept:     "intersect_except",
+	CapabilityNestedSetOperations: "nested_set_operations",
+	CapabilityDistinctOn:          "distinct_on",
+	CapabilityNullsOrder:          "nulls_order",
+	CapabilityILike:               "ilike",
+	CapabilityAggregateFilter:     "aggregate_filter",
+	CapabilitySeries:              "series",
+	CapabilityBooleans:            "booleans",
+	CapabilityWindows:             "windows",
+	CapabilityCommonTables:        "common_tables",
+	CapabilityQualify:             "qualify",
+	CapabilityStarExcept:          "star_except",
+	CapabilityLimitBy:             "limit_by",
+	CapabilityRightJoin:           "right_join",
+	CapabilityOffset:              "offset",
+	CapabilityWindowFrames:        "window_frames",
+	CapabilityRegex:               "regex",
+	CapabilityJoinUsing:           "join_using",
+	CapabilityFloatDivision:       "float_division",
+	CapabilitySafeDivide:          "safe_divide",
+	CapabilityImplicitConversions: "implicit_conversions",
+	CapabilityRecursiveKeyword:    "recursive_keyword",
+	CapabilityImplicitDistinct:    "implicit_distinct",
+	CapabilityBareQualify:         "bare_qualify",
+	CapabilityPathQualifiers:      "path_qualifiers",
+	CapabilityStarExclude:         "star_exclude",
+	CapabilityLikeBackslash:       "like_backslash",
+	CapabilityLikeSets:            "like_sets",
+	CapabilityCaseSensitiveLike:   "case_sensitive_like",
+}
+
+// String returns the string representation of the Capability
+func (c Capability) String() string {
+	return capabilityStringMap[c]
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Converts
+// the string representation into the Capability, or returns an error if it
+// is invalid.
+func (c *Capability) UnmarshalText(text []byte) error {
+	str := string(text)
+	for capability, name := range capabilityStringMap {
+		if name == str {
+			*c = capability
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid Capability '%s'", str)
+}
+
+// holds the dialects added by RegisterDialect
+var (
+	registeredDialects = map[syntax.Dialect]Dialect{}
+	registeredLock     sync.RWMutex
+)
+
+// RegisterDialect adds a Dialect of the given name, which queries may then
+// target with the "prql" header. The returned syntax.Dialect identifies it
+// within Options and errors.
+func RegisterDialect(name string, impl Dialect) (syntax.Dialect, error) {
+	if impl == nil {
+		return syntax.DialectGeneric, fmt.Errorf("dialect '%s' has no implementation", name)
+	}
+
+	registeredLock.Lock()
+	defer registeredLock.Unlock()
+
+	dialect, err := syntax.RegisterDialect(name)
+	if err != nil {
+		return dialect, err
+	}
+	registeredDialects[dialect] = impl
+	return dialect, nil
+}
+
+// dialectOf returns the Dialect generating the SQL of the syntax.Dialect.
+func dialectOf(dialect syntax.Dialect) Dialect {
+	registeredLock.RLock()
+	defer registeredLock.RUnlock()
+
+	if impl, ok := registeredDialects[dialect]; ok {
+		return impl
+	}
+	return BuiltinDialect(dialect)
+}
+
+// builtinDialect is the Dialect of the syntax.Dialect values declared by the
+// syntax package.
+type builtinDialect struct {
+	dialect syntax.Dialect
+
+	// mysql is the version of the server targeted by the mysql dialect
+	mysql MySQLVersion
+
+	// sqlite is the version of the library targeted by the sqlite dialect
+	sqlite SQLiteVersion
+
+	// identifiers declares which names are quoted
+	identifiers IdentifierStyle
+}
+
+// BuiltinDialect returns the Dialect of a syntax.Dialect declared by the syntax
+// package, which other dialects may embed to change only some of the hooks.
+// Other values return the generic dialect.
+func BuiltinDialect(dialect syntax.Dialect) Dialect {
+	if dialect > syntax.DialectSnowflake {
+		dialect = syntax.DialectGeneric
+	}
+	return builtinDialect{dialect: dialect}
+}
+
+// version returns the version of the database targeted, when it is older than
+// the latest.
+func (d builtinDialect) version() string {
+	if d.dialect == syntax.DialectMYSQL && d.mysql != MySQL80 {
+		return d.mysql.String()
+	} else if d.dialect == syntax.DialectSQLite && d.sqlite != SQLite339 {
+		return d.sqlite.String()
+	}
+	return ""
+}
+
+// QuoteIdent implements Dialect, quoting any names which are not lower case,
+// or are reserved words, unless the identifier style says otherwise. Snowflake
+// folds bare names to upper case, so the reserved words are quoted in upper
+// case when left to fold.
+func (d builtinDialect) QuoteIdent(name string) string {
+	switch d.identifiers {
+	case IdentifiersQuoted:
+		return d.quote(name)
+	case IdentifiersBare:
+		if !reFoldedIdent.MatchString(name) {
+			return d.quote(name)
+		}
+		lower := strings.ToLower(name)
+		if !reservedWords[lower] && !dialectReservedWords[d.dialect][lower] {
+			return name
+		}
+		if d.dialect == syntax.DialectSnowflake {
+			return d.quote(strings.ToUpper(name))
+		}
+		return d.quote(name)
+	}
+
+	if reBareIdent.MatchString(name) && !reservedWords[name] && !dialectReservedWords[d.dialect][name] {
+		return name
+	}
+	return d.quote(name)
+}
+
+// quote quotes a name with the quotes of the dialect.
+func (d builtinDialect) quote(name string) string {
+	switch d.dialect {
+	case syntax.DialectMYSQL, syntax.DialectBigQuery, syntax.DialectHive:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case syntax.DialectMSSQL:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Literal implements Dialect. Backslashes within strings are escaped for the
+// dialects which use them as escape characters. BigQuery and Hive also escape
+// quotes with a backslash, as BigQuery rejects doubled quotes, and Hive reads
+// them as two literals to concatenate. MSSQL and SQLite do not have
+// typed literals, so convert strings instead. SQLite keeps dates as text in
+// the format of it's date functions. Hive has no type for times, and separates
+// the date and time of a timestamp with a space.
+func (d builtinDialect) Literal(typ syntax.Type, value string) string {
+	if d.dialect == syntax.DialectHive {
+		switch typ {
+		case syntax.TypeTime:
+			return "'" + value + "'"
+		case syntax.TypeTimestamp:
+			return "TIMESTAMP '" + strings.Replace(value, "T", " ", 1) + "'"
+		}
+	}
+	if d.dialect == syntax.DialectSQLite {
+		switch typ {
+		case syntax.TypeDate:
+			return "DATE('" + value + "')"
+		case syntax.TypeTime:
+			return "TIME('" + value + "')"
+		case syntax.TypeTimestamp:
+			return "DATETIME('" + value + "')"
+		}
+	}
+	if d.dialect == syntax.DialectMSSQL {
+		switch typ {
+		case syntax.TypeDate:
+			return "CAST('" + value + "' AS DATE)"
+		case syntax.TypeTime:
+			return "CAST('" + value + "' AS TIME)"
+		case syntax.TypeTimestamp:
+			return "CAST('" + value + "' AS DATETIME2)"
+		}
+	}
+	switch typ {
+	case syntax.TypeString:
+		if backslashEscapes(d.dialect) {
+			value = strings.ReplaceAll(value, `\`, `\\`)
+		}
+		if d.dialect == syntax.DialectBigQuery || d.dialect == syntax.DialectHive {
+			return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+		}
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case syntax.TypeDate:
+		return "DATE '" + value + "'"
+	case syntax.TypeTime:
+		return "TIME '" + value + "'"
+	case syntax.TypeTimestamp:
+		return "TIMESTAMP '" + value + "'"
+	}
+	return value
+}
+
+// Boolean implements Dialect. MSSQL has no boolean type, and uses bits instead.
+// SQLite keeps booleans as the integers they are stored as.
+func (d builtinDialect) Boolean(value bool) string {
+	if d.dialect == syntax.DialectMSSQL || d.dialect == syntax.DialectSQLite {
+		if value {
+			return "1"
+		}
+		return "0"
+	}
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// Limit implements Dialect.
+//
+// MSSQL uses "TOP" when there is no offset. Otherwise it uses "OFFSET ...
+// FETCH", which requires an ORDER BY clause. MySQL places the offset before
+// the limit, as "LIMIT offset, limit".
+func (d builtinDialect) Limit(limit, offset int64, ordered bool) (string, []string) {
+	if limit < 0 && offset == 0 {
+		return "", nil
+	}
+
+	limitStr := strconv.FormatInt(limit, 10)
+	offsetStr := strconv.FormatInt(offset, 10)
+
+	switch d.dialect {
+	case syntax.DialectMSSQL:
+		if offset == 0 {
+			return "TOP (" + limitStr + ")", nil
+		}
+		var clauses []string
+		if !ordered {
+			clauses = append(clauses, "ORDER BY (SELECT NULL)")
+		}
+		clauses = append(clauses, "OFFSET "+offsetStr+" ROWS")
+		if limit >= 0 {
+			clauses = append(clauses, "FETCH NEXT "+limitStr+" ROWS ONLY")
+		}
+		return "", clauses
+
+	case syntax.DialectANSI:
+		var clauses []string
+		if offset > 0 {
+			clauses = append(clauses, "OFFSET "+offsetStr+" ROWS")
+		}
+		if limit >= 0 {
+			clauses = append(clauses, "FETCH FIRST "+limitStr+" ROWS ONLY")
+		}
+		return "", clauses
+	}
+
+	// Dialects which do not allow an offset without a limit use the largest
+	// limit accepted instead
+	if limit < 0 {
+		switch d.dialect {
+		case syntax.DialectSQLite:
+			limitStr = "-1"
+		case syntax.DialectMYSQL:
+			limitStr = "18446744073709551615"
+		case syntax.DialectBigQuery, syntax.DialectClickHouse, syntax.DialectHive:
+			limitStr = "9223372036854775807"
+		default:
+			return "", []string{"OFFSET " + offsetStr}
+		}
+	}
+
+	clause := "LIMIT " + limitStr
+	if offset > 0 && d.dialect == syntax.DialectMYSQL {
+		clause = "LIMIT " + offsetStr + ", " + limitStr
+	} else if offset > 0 {
+		clause += " OFFSET " + offsetStr
+	}
+	return "", []string{clause}
+}
+
+// Concat implements Dialect. MySQL, MSSQL and Hive use the "CONCAT" function,
+// as "||" is a logical or within MySQL, and MSSQL and older versions of Hive
+// do not have it.
+func (d builtinDialect) Concat(parts []string) string {
+	switch d.dialect {
+	case syntax.DialectMYSQL, syntax.DialectMSSQL, syntax.DialectHive:
+		return "CONCAT(" + strings.Join(parts, ", ") + ")"
+	}
+	return strings.Join(parts, " || ")
+}
+
+// Function implements Dialect using the templates of stdlib.
+func (d builtinDialect) Function(name, unit string) (string, bool) {
+	if unit != "" {
+		if sql, ok := stdlib[d.dialect][name+":"+unit]; ok {
+			return sql, true
+		}
+	}
+	sql, ok := stdlib[d.dialect][name]
+	return sql, ok
+}
+
+// Cast implements Dialect. Postgres casts with the "::" operator, and SQLite
+// converts into dates with it's date functions, as "CAST(value AS DATE)"
+// would convert the text into a number.
+func (d builtinDialect) Cast(typ syntax.Type) string {
+	switch d.dialect {
+	case syntax.DialectPostgres:
+		return "{0}::" + d.typeName(typ)
+	case syntax.DialectSQLite:
+		if fn, ok := sqliteDateFunctions[typ]; ok {
+			return fn + "({0})"
+		}
+	}
+	return "CAST({0} AS " + d.typeName(typ) + ")"
+}
+
+// typeNames holds the SQL names of types which differ from the generic dialect,
+// by dialect
+var typeNames = map[syntax.Dialect]map[syntax.Type]string{
+	syntax.DialectBigQuery: {
+		syntax.TypeBoolean: "BOOL",
+		syntax.TypeInteger: "INT64",
+		syntax.TypeFloat:   "FLOAT64",
+		syntax.TypeString:  "STRING",
+	},
+	syntax.DialectClickHouse: {
+		syntax.TypeBoolean:   "Bool",
+		syntax.TypeInteger:   "Int64",
+		syntax.TypeFloat:     "Float64",
+		syntax.TypeString:    "String",
+		syntax.TypeDate:      "Date",
+		syntax.TypeTimestamp: "DateTime",
+	},
+	syntax.DialectHive: {
+		syntax.TypeInteger: "BIGINT",
+		syntax.TypeFloat:   "DOUBLE",
+		syntax.TypeString:  "STRING",
+	},
+	syntax.DialectMSSQL: {
+		syntax.TypeBoolean:   "BIT",
+		syntax.TypeFloat:     "FLOAT",
+		syntax.TypeString:    "VARCHAR(MAX)",
+		syntax.TypeTimestamp: "DATETIME2",
+	},
+	// MySQL only casts into a subset of it's types
+	syntax.DialectMYSQL: {
+		syntax.TypeBoolean:   "SIGNED",
+		syntax.TypeInteger:   "SIGNED",
+		syntax.TypeFloat:     "DOUBLE",
+		syntax.TypeString:    "CHAR",
+		syntax.TypeTimestamp: "DATETIME",
+	},
+	syntax.DialectSQLite: {
+		syntax.TypeBoolean: "INTEGER",
+		syntax.TypeFloat:   "REAL",
+		syntax.TypeString:  "TEXT",
+	},
+	syntax.DialectSnowflake: {syntax.TypeFloat: "FLOAT"},
+}
+
+// typeName returns the name of the type within the dialect.
+func (d builtinDialect) typeName(typ syntax.Type) string {
+	if name, ok := typeNames[d.dialect][typ]; ok {
+		return name
+	}
+	switch typ {
+	case syntax.TypeFloat:
+		return "DOUBLE PRECISION"
+	case syntax.TypeInteger:
+		return "INTEGER"
+	case syntax.TypeString:
+		return "VARCHAR"
+	}
+	return strings.ToUpper(typ.String())
+}
+
+// Supports implements Dialect using the capabilities of the dialect, less
+// those lacking from the version targeted.
+func (d builtinDialect) Supports(capability Capability) bool {
+	if !hasCapability(dialectCapabilities[d.dialect], capability) {
+		return false
+	}
+	switch d.dialect {
+	case syntax.DialectMYSQL:
+		return !hasCapability(mySQLLacks[d.mysql], capability)
+	case syntax.DialectSQLite:
+		return !hasCapability(sqliteLacks[d.sqlite], capability)
+	}
+	return true
+}
+
+// genericCapabilities holds the capabilities of the generic dialect, which
+// most databases share
+var genericCapabilities = []Capability{
+	CapabilityFullJoin, CapabilityRightJoin, CapabilityRecursiveTables, CapabilityIntersectExcept,
+	CapabilityNestedSetOperations, CapabilityNullsOrder, CapabilityBooleans, CapabilityWindows,
+	CapabilityWindowFrames, CapabilityCommonTables, CapabilityOffset, CapabilityRegex, CapabilityJoinUsing,
+	CapabilityFloatDivision, CapabilityImplicitConversions, CapabilityRecursiveKeyword,
+	CapabilityImplicitDistinct, CapabilityBareQualify, CapabilityPathQualifiers, CapabilityCaseSensitiveLike,
+}
+
+// dialectCapabilities holds the capabilities of each dialect, at the latest
+// version of it's database
+var dialectCapabilities = map[syntax.Dialect][]Capability{
+	syntax.DialectGeneric: genericCapabilities,
+	syntax.DialectANSI:    genericCapabilities,
+	syntax.DialectBigQuery: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityQualify, CapabilityStarExcept, CapabilitySafeDivide, CapabilityLikeBackslash},
+		[]Capability{CapabilityImplicitDistinct, CapabilityBareQualify, CapabilityPathQualifiers}),
+	syntax.DialectClickHouse: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityILike, CapabilityLimitBy, CapabilityLikeBackslash},
+		[]Capability{CapabilityRecursiveTables, CapabilityImplicitConversions}),
+	syntax.DialectHive: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityLikeBackslash},
+		[]Capability{CapabilityRecursiveTables, CapabilityNestedSetOperations, CapabilityOffset}),
+	syntax.DialectMSSQL: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityLikeSets},
+		[]Capability{
+			CapabilityNestedSetOperations, CapabilityNullsOrder, CapabilityBooleans, CapabilityWindowFrames,
+			CapabilityRegex, CapabilityJoinUsing, CapabilityFloatDivision, CapabilityRecursiveKeyword,
+			CapabilityCaseSensitiveLike,
+		}),
+	syntax.DialectMYSQL: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityLikeBackslash},
+		[]Capability{CapabilityFullJoin, CapabilityNullsOrder, CapabilityCaseSensitiveLike}),
+	syntax.DialectPostgres: withCapabilities(genericCapabilities,
+		[]Capability{
+			CapabilityDistinctOn, CapabilityILike, CapabilityAggregateFilter, CapabilitySeries, CapabilityLikeBackslash,
+		},
+		[]Capability{CapabilityFloatDivision}),
+	syntax.DialectSQLite: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityAggregateFilter},
+		[]Capability{CapabilityNestedSetOperations, CapabilityRegex, CapabilityFloatDivision, CapabilityCaseSensitiveLike}),
+	syntax.DialectSnowflake: withCapabilities(genericCapabilities,
+		[]Capability{CapabilityILike, CapabilityQualify, CapabilityStarExcept, CapabilityStarExclude}, nil),
+}
+
+// mySQLLacks holds the capabilities lacking from each version of MySQL
+var mySQLLacks = map[MySQLVersion][]Capability{
+	MySQL80: {CapabilityIntersectExcept},
+	MySQL57: {
+		CapabilityIntersectExcept, CapabilityWindows, CapabilityWindowFrames, CapabilityCommonTables,
+		CapabilityRecursiveTables,
+	},
+}
+
+// sqliteLacks holds the capabilities lacking from each version of SQLite
+var sqliteLacks = map[SQLiteVersion][]Capability{
+	SQLite325: {
+		CapabilityFullJoin, CapabilityRightJoin, CapabilityNullsOrder, CapabilityAggregateFilter,
+		CapabilityWindowFrames,
+	},
+}
+
+// withCapabilities returns the capabilities with some added and others
+// removed.
+func withCapabilities(capabilities, added, removed []Capability) []Capability {
+	result := make([]Capability, 0, len(capabilities)+len(added))
+	for _, capability := range capabilities {
+		if !hasCapability(removed, capability) {
+			result = append(result, capability)
+		}
+	}
+	return append(result, added...)
+}
+
+func hasCapability(capabilities []Capability, capability Capability) bool {
+	for _, other := range capabilities {
+		if other == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns the capabilities which the dialect supports, in the
+// order they are declared.
+func Capabilities(dialect Dialect) []Capability {
+	var capabilities []Capability
+	for capability := Capability(0); int(capability) < len(capabilityStringMap); capability++ {
+		if dialect.Supports(capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
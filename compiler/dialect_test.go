@@ -0,0 +1,230 @@
+package compiler_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// upperDialect is a custom dialect based on ANSI SQL, which quotes every
+// identifier in upper case and has no FULL JOIN.
+type upperDialect struct {
+	compiler.Dialect
+}
+
+func (upperDialect) QuoteIdent(name string) string {
+	return `"` + strings.ToUpper(name) + `"`
+}
+
+func (d upperDialect) Supports(capability compiler.Capability) bool {
+	return capability != compiler.CapabilityRecursiveTables && d.Dialect.Supports(capability)
+}
+
+func (d upperDialect) Function(name, unit string) (string, bool) {
+	if name == "text.length" {
+		return "CHARACTER_LENGTH", true
+	}
+	return d.Dialect.Function(name, unit)
+}
+
+// registerUpper registers the custom dialect once, as the registered names
+// are kept between runs of the tests.
+func registerUpper(t *testing.T) syntax.Dialect {
+	t.Helper()
+
+	var dialect syntax.Dialect
+	if dialect.UnmarshalText([]byte("upper")) == nil {
+		return dialect
+	}
+	dialect, err := compiler.RegisterDialect("upper", upperDialect{compiler.BuiltinDialect(syntax.DialectANSI)})
+	if err != nil {
+		t.Fatalf("unexpected error registering dialect: %s", err)
+	}
+	return dialect
+}
+
+func TestCompileRegisteredDialect(t *testing.T) {
+	dialect := registerUpper(t)
+	if dialect.String() != "upper" {
+		t.Errorf("expected registered dialect to be named upper, received %s", dialect.String())
+	}
+
+	runCompileTests(t, []compileTest{
+		{
+			name: "hooks",
+			source: `prql dialect:upper
+from employees
+derive [n = text.length name, flag = true]
+take 6..10`,
+			expected: `
+SELECT "EMPLOYEES".*, CHARACTER_LENGTH("NAME") AS "N", TRUE AS "FLAG"
+FROM "EMPLOYEES"
+OFFSET 5 ROWS
+FETCH FIRST 5 ROWS ONLY`,
+		},
+	})
+
+	query, err := parser.Parse("prql dialect:upper\nfrom a\nloop (filter n < 5)")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+	_, err = compiler.Compile(query, compiler.Options{})
+
+	var unsupported compiler.UnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Dialect != dialect {
+		t.Errorf("expected UnsupportedError for dialect upper, received %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "'loop' is not supported by dialect upper") {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
+
+// forkDialect is a custom dialect based on Postgres, changing only it's
+// quoting.
+type forkDialect struct {
+	compiler.Dialect
+}
+
+func (forkDialect) QuoteIdent(name string) string {
+	return `"` + name + `"`
+}
+
+func TestCompileForkedDialect(t *testing.T) {
+	var dialect syntax.Dialect
+	if dialect.UnmarshalText([]byte("pgfork")) != nil {
+		var err error
+		if dialect, err = compiler.RegisterDialect("pgfork", forkDialect{compiler.BuiltinDialect(syntax.DialectPostgres)}); err != nil {
+			t.Fatalf("unexpected error registering dialect: %s", err)
+		}
+	}
+
+	runCompileTests(t, []compileTest{
+		{
+			name: "keeps the SQL of postgres",
+			source: `prql dialect:pgfork
+from t
+filter name ~= "^A"
+derive [
+  h = a / b,
+  r = math.round 2 (a + b),
+  due = created + 2weeks,
+  s = (created | date.to_text "%d/%m/%Y"),
+]`,
+			expected: `
+SELECT "t".*, "a"::DOUBLE PRECISION / "b" AS "h", ROUND(("a" + "b")::NUMERIC, 2) AS "r", "created" + INTERVAL '2 weeks' AS "due", TO_CHAR("created", 'DD/MM/YYYY') AS "s"
+FROM "t"
+WHERE "name" ~ '^A'`,
+		},
+	})
+}
+
+func TestRegisterDialectErrors(t *testing.T) {
+	registerUpper(t)
+
+	if _, err := compiler.RegisterDialect("upper", upperDialect{}); err == nil {
+		t.Error("expected an error registering a dialect twice")
+	}
+	if _, err := compiler.RegisterDialect("postgres", upperDialect{}); err == nil {
+		t.Error("expected an error registering a built-in dialect name")
+	}
+	if _, err := compiler.RegisterDialect("empty", nil); err == nil {
+		t.Error("expected an error registering a dialect without an implementation")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		dialect    syntax.Dialect
+		capability compiler.Capability
+		expected   bool
+	}{
+		{syntax.DialectGeneric, compiler.CapabilityFullJoin, true},
+		{syntax.DialectPostgres, compiler.CapabilityDistinctOn, true},
+		{syntax.DialectMYSQL, compiler.CapabilityFullJoin, false},
+		{syntax.DialectMSSQL, compiler.CapabilityBooleans, false},
+		{syntax.DialectMSSQL, compiler.CapabilityWindowFrames, false},
+		{syntax.DialectMSSQL, compiler.CapabilityJoinUsing, false},
+		{syntax.DialectBigQuery, compiler.CapabilityQualify, true},
+		{syntax.DialectSnowflake, compiler.CapabilityStarExcept, true},
+		{syntax.DialectClickHouse, compiler.CapabilityRecursiveTables, false},
+		{syntax.DialectHive, compiler.CapabilityOffset, false},
+		{syntax.DialectSQLite, compiler.CapabilityRegex, false},
+		{syntax.DialectPostgres, compiler.CapabilityFloatDivision, false},
+		{syntax.DialectBigQuery, compiler.CapabilityImplicitDistinct, false},
+		{syntax.DialectClickHouse, compiler.CapabilityImplicitConversions, false},
+		{syntax.DialectMSSQL, compiler.CapabilityLikeSets, true},
+		{syntax.DialectSQLite, compiler.CapabilityCaseSensitiveLike, false},
+		{syntax.DialectPostgres, compiler.CapabilityCaseSensitiveLike, true},
+	}
+
+	for _, test := range tests {
+		supported := false
+		for _, capability := range compiler.Capabilities(compiler.BuiltinDialect(test.dialect)) {
+			supported = supported || capability == test.capability
+		}
+		if supported != test.expected {
+			t.Errorf("expected %s support of %s to be %t", test.dialect.String(), test.capability.String(), test.expected)
+		}
+	}
+}
+
+func TestUnsupportedErrorTransform(t *testing.T) {
+	tests := map[string]string{
+		"prql dialect:sqlite\nfrom t\nfilter (name ~= \"a\")":                   "filter",
+		"prql dialect:mssql\nfrom t\nwindow range:-1..0 (derive [s = sum x])":   "window",
+		"prql dialect:mysql\nfrom t\nderive [r = rank]":                         "derive",
+		"prql dialect:sqlite\nfrom t\njoin (from u | filter n ~= \"a\") [==id]": "filter",
+		"prql dialect:mysql\nfrom t\ngroup id (sort x | take 1)":                "group",
+	}
+
+	for source, transform := range tests {
+		query, err := parser.Parse(source)
+		if err != nil {
+			t.Fatalf("unexpected parse error: %s", err)
+		}
+		_, err = compiler.Compile(query, compiler.Options{MySQL: compiler.MySQL57})
+
+		var unsupported compiler.UnsupportedError
+		if !errors.As(err, &unsupported) {
+			t.Errorf("expected UnsupportedError, received %v", err)
+		} else if unsupported.Transform != transform {
+			t.Errorf("expected transform %q, received %q", transform, unsupported.Transform)
+		} else if !strings.Contains(err.Error(), " in '"+transform+"' is not supported") {
+			t.Errorf("expected the error to name the transform %q, received %q", transform, err.Error())
+		}
+	}
+
+	err := compiler.UnsupportedError{Dialect: syntax.DialectMYSQL, Feature: "window function 'rank'", Transform: "derive", Version: "5.7"}
+	if expected := "window function 'rank' in 'derive' is not supported by dialect mysql 5.7"; err.Error() != expected {
+		t.Errorf("expected %q, received %q", expected, err.Error())
+	}
+	err = compiler.UnsupportedError{Dialect: syntax.DialectSQLite, Feature: "'loop'", Transform: "loop"}
+	if expected := "'loop' is not supported by dialect sqlite"; err.Error() != expected {
+		t.Errorf("expected %q, received %q", expected, err.Error())
+	}
+}
+
+func TestDialectStringLiterals(t *testing.T) {
+	tests := []struct {
+		dialect  syntax.Dialect
+		value    string
+		expected string
+	}{
+		{syntax.DialectGeneric, `it's`, `'it''s'`},
+		{syntax.DialectPostgres, `it's \ here`, `'it''s \ here'`},
+		{syntax.DialectMYSQL, `it's \ here`, `'it''s \\ here'`},
+		{syntax.DialectBigQuery, `it's \ here`, `'it\'s \\ here'`},
+		{syntax.DialectHive, `it's \ here`, `'it\'s \\ here'`},
+	}
+
+	for _, test := range tests {
+		literal := compiler.BuiltinDialect(test.dialect).Literal(syntax.TypeString, test.value)
+		if literal != test.expected {
+			t.Errorf("expected %s literal %s, received %s", test.dialect.String(), test.expected, literal)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package compiler
+
+import (
+	"reflect"
+)
+
+// isDistinct returns true if the grouping columns are exactly the columns of
+// the relation. Taking the first row of each group then selects the distinct
+// rows, whatever their order.
+func (r *relation) isDistinct(partition []sqlExpr) bool {
+	for _, col := range r.columns {
+		if col.hidden {
+			return false
+		}
+	}
+	columns, known := r.outputColumns()
+	if !known || len(columns) == 0 {
+		return false
+	}
+
+	matched := make([]bool, len(partition))
+	for _, col := range columns {
+		found := false
+		for i, part := range partition {
+			if reflect.DeepEqual(col.expr, part) {
+				matched[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// distinct removes duplicate rows from the relation with "SELECT DISTINCT".
+func (r *relation) distinct() {
+	r.query.distinct = true
+	r.stage = stageDistinct
+}
+
+// selected returns true if each value of the order is a column of the
+// relation, as required for ordering the rows of "SELECT DISTINCT".
+func (r *relation) selected(sort []sortItem) bool {
+	columns, _ := r.outputColumns()
+	for _, item := range sort {
+		found := false
+		for _, col := range columns {
+			found = found || reflect.DeepEqual(col.expr, item.expr)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
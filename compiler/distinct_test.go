@@ -0,0 +1,97 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileDistinct(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "group by every column",
+			source: `
+from t
+select [a, b]
+group [b, a] (take 1)`,
+			expected: `
+SELECT DISTINCT a, b
+FROM t`,
+		},
+		{
+			name: "group by computed column",
+			source: `
+from t
+select [a, b = x + 1]
+group [a, b] (sort a | take 1)`,
+			expected: `
+SELECT DISTINCT a, x + 1 AS b
+FROM t`,
+		},
+		{
+			name: "sorted and taken",
+			source: `
+from t
+select [a, b]
+group [a, b] (take 1)
+sort -a
+take 10`,
+			expected: `
+SELECT DISTINCT a, b
+FROM t
+ORDER BY a DESC
+LIMIT 10`,
+		},
+		{
+			name: "sorted by unselected value",
+			source: `
+from t
+select [a, b]
+group [a, b] (take 1)
+sort (a + b)`,
+			expected: `
+SELECT a, b
+FROM (
+  SELECT DISTINCT a, b
+  FROM t
+) AS table_0
+ORDER BY a + b`,
+		},
+		{
+			name: "filtered",
+			source: `
+from t
+select [a, b]
+group [a, b] (take 1)
+filter b > 2`,
+			expected: `
+SELECT a, b
+FROM (
+  SELECT DISTINCT a, b
+  FROM t
+) AS table_0
+WHERE b > 2`,
+		},
+		{
+			name: "postgres prefers distinct",
+			source: `
+prql dialect:postgres
+from t
+select [a, b]
+group [a, b] (take 1)`,
+			expected: `
+SELECT DISTINCT a, b
+FROM t`,
+		},
+		{
+			name: "group by some columns",
+			source: `
+from t
+select [a, b, c]
+group [a, b] (take 1)`,
+			expected: `
+SELECT a, b, c
+FROM (
+  SELECT a, b, c, ROW_NUMBER() OVER (PARTITION BY a, b) AS _expr_0
+  FROM t
+) AS table_0
+WHERE _expr_0 <= 1`,
+		},
+	})
+}
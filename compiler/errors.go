@@ -0,0 +1,94 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// Error is returned when a parsed query cannot be compiled, such as an unknown
+// name or an invalid transform. It holds the position within the PRQL source
+// of the offending expression.
+type Error struct {
+	Position syntax.Position
+	Message  string
+}
+
+// Error implements the `error` interface, prefixing the message with the
+// position when it is known.
+func (e Error) Error() string {
+	if e.Position.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Position.String(), e.Message)
+}
+
+// errorf creates a new Error at the given position.
+func errorf(pos syntax.Position, format string, args ...any) error {
+	return Error{pos, fmt.Sprintf(format, args...)}
+}
+
+// UnsupportedError is returned when the query uses a feature which the target
+// dialect does not support, and which cannot be emulated.
+type UnsupportedError struct {
+	Position syntax.Position
+	Dialect  syntax.Dialect
+	Feature  string
+
+	// Transform is the name of the transform using the feature, such as
+	// "derive", or empty if it is not used within one
+	Transform string
+
+	// Version is the version of the database targeted, when one was given
+	// and it lacks the feature
+	Version string
+}
+
+// Error implements the `error` interface, prefixing the message with the
+// position when it is known. The transform is named unless the feature names
+// it already.
+func (e UnsupportedError) Error() string {
+	feature := e.Feature
+	if e.Transform != "" && !strings.HasPrefix(feature, "'"+e.Transform+"'") {
+		feature += " in '" + e.Transform + "'"
+	}
+	msg := fmt.Sprintf("%s is not supported by dialect %s", feature, e.Dialect.String())
+	if e.Version != "" {
+		msg += " " + e.Version
+	}
+	if e.Position.Line == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s: %s", e.Position.String(), msg)
+}
+
+// unsupported creates a new UnsupportedError for the dialect being compiled.
+func (c *compiler) unsupported(pos syntax.Position, feature string) error {
+	err := UnsupportedError{Position: pos, Dialect: c.dialect, Feature: feature, Transform: c.transform}
+	if builtin, ok := c.target.(builtinDialect); ok {
+		err.Version = builtin.version()
+	}
+	return err
+}
+
+// Warning is a problem within a query which does not stop it compiling, but
+// is likely a mistake.
+type Warning struct {
+	Position syntax.Position
+	Message  string
+}
+
+// String formats the warning, prefixing the message with the position when it
+// is known.
+func (w Warning) String() string {
+	if w.Position.Line == 0 {
+		return w.Message
+	}
+	return fmt.Sprintf("%s: %s", w.Position.String(), w.Message)
+}
+
+// warnf adds a new Warning at the given position.
+func (c *compiler) warnf(pos syntax.Position, format string, args ...any) {
+	c.warnings = append(c.warnings, Warning{pos, fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,102 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// exclude removes columns from the relation by name, "select ![a, b]".
+//
+// Columns computed by the pipeline are simply removed. Columns which are only
+// known to be within a relation's star are excluded with "* EXCEPT (...)",
+// for the dialects which allow it. A name qualified with it's relation, such
+// as "e.a", picks the star to exclude it from when there are many.
+func (r *relation) exclude(list syntax.List) error {
+	for _, item := range list.Items {
+		ident, ok := item.(syntax.Ident)
+		if !ok {
+			return errorf(item.Pos(), "'select' expects the names of the columns to exclude but found %s", item.String())
+		}
+
+		relation, name := "", ident.Name
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			relation, name = name[:dot], name[dot+1:]
+		}
+
+		col := r.lookup(name)
+		if relation != "" && col != nil {
+			if ref, ok := col.expr.(columnRef); !ok || ref.relation != relation {
+				col = nil
+			}
+		}
+		if col != nil {
+			r.remove(col)
+			if !col.covered {
+				continue
+			}
+		}
+
+		star, err := r.excludeStar(ident, relation, col != nil)
+		if err != nil {
+			return err
+		}
+		if star == nil {
+			return errorf(ident.Pos(), "unknown name '%s'", ident.Name)
+		}
+		if !r.c.target.Supports(CapabilityStarExcept) {
+			return r.c.unsupported(ident.Pos(), "excluding column '"+ident.Name+"' from '*'")
+		}
+		star.excluded = append(star.excluded, name)
+	}
+	return nil
+}
+
+// excludeStar returns the star column a name is excluded from, or nil if
+// there is none. A name covered by an earlier split is within the first star.
+func (r *relation) excludeStar(ident syntax.Ident, relation string, covered bool) (*column, error) {
+	var found *column
+	for _, col := range r.columns {
+		star, ok := col.expr.(starExpr)
+		if !ok || (relation != "" && star.relation != relation) {
+			continue
+		}
+		if covered {
+			return col, nil
+		}
+		if found != nil {
+			return nil, errorf(ident.Pos(), "column '%s' may belong to any of the relations, so must be qualified with it's relation", ident.Name)
+		}
+		found = col
+	}
+	return found, nil
+}
+
+// remove removes the column from the relation.
+func (r *relation) remove(col *column) {
+	columns := make([]*column, 0, len(r.columns))
+	for _, other := range r.columns {
+		if other != col {
+			columns = append(columns, other)
+		}
+	}
+	r.columns = columns
+}
+
+// excluded returns true if the name is excluded from each star column, so is
+// not available to an outer query.
+func (r *relation) excluded(name string) bool {
+	for _, col := range r.columns {
+		if !col.isStar() {
+			continue
+		}
+		found := false
+		for _, except := range col.excluded {
+			found = found || except == name
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
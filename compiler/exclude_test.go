@@ -0,0 +1,44 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileExclude(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "computed columns",
+			source: `
+from t
+select [a, b, c = a + b]
+select ![b, c]`,
+			expected: `
+SELECT a
+FROM t`,
+		},
+		{
+			name: "hidden columns",
+			source: `prql dialect:bigquery
+from t
+sort created
+select ![created]
+take 10
+filter x > 1`,
+			expected: `
+SELECT * EXCEPT (_expr_0)
+FROM (
+  SELECT t.* EXCEPT (created), created AS _expr_0
+  FROM t
+  ORDER BY created
+  LIMIT 10
+) AS table_0
+WHERE x > 1
+ORDER BY _expr_0`,
+		},
+	})
+}
+
+func TestCompileExcludeErrors(t *testing.T) {
+	compileError(t, "from t\nselect ![a]", "2:10: excluding column 'a' from '*' in 'select' is not supported by dialect generic")
+	compileError(t, "from t\nselect [a]\nselect ![b]", "3:10: unknown name 'b'")
+	compileError(t, "from t\nselect ![a + 1]", "2:10: 'select' expects the names of the columns to exclude but found (a + 1)")
+	compileError(t, "prql dialect:bigquery\nfrom a\njoin b [==id]\nselect ![x]", "4:10: column 'x' may belong to any of the relations, so must be qualified with it's relation")
+}
@@ -0,0 +1,360 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// binaryOperators maps the PRQL binary operators to their SQL equivelent
+var binaryOperators = map[string]string{
+	"==":  "=",
+	"!=":  "<>",
+	">":   ">",
+	">=":  ">=",
+	"<":   "<",
+	"<=":  "<=",
+	"+":   "+",
+	"-":   "-",
+	"*":   "*",
+	"/":   "/",
+	"%":   "%",
+	"and": "AND",
+	"or":  "OR",
+}
+
+// translate converts a PRQL expression into a SQL expression, resolving any
+// names against the columns of the relation.
+func (r *relation) translate(expr syntax.Expr) (sqlExpr, error) {
+	switch e := expr.(type) {
+	case syntax.Literal:
+		return literalExpr{e.Type, e.Value}, nil
+
+	case syntax.Interval:
+		return intervalExpr{e.Value, e.Unit}, nil
+
+	case syntax.Ident:
+		return r.resolve(e)
+
+	case syntax.Unary:
+		operand, err := r.translate(e.Operand)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case "-":
+			return unaryExpr{"-", operand}, nil
+		case "+":
+			return operand, nil
+		case "!":
+			return unaryExpr{"NOT", operand}, nil
+		}
+		return nil, errorf(e.Pos(), "unexpected operator '%s'", e.Op)
+
+	case syntax.Binary:
+		left, err := r.translate(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := r.translate(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return r.c.translateBinary(e, left, right)
+
+	case syntax.Call:
+		// Declared functions hide the standard functions of the same name
+		if fn, ok := r.c.functions[e.Name]; ok {
+			return r.callDeclared(fn, e)
+		}
+		if e.Name == "as" {
+			return r.translateCast(e)
+		}
+		var named map[string]sqlExpr
+		for name, arg := range e.Named {
+			if !acceptsNamed(e.Name, name) {
+				return nil, errorf(arg.Pos(), "function '%s' has no parameter named '%s'", e.Name, name)
+			}
+			translated, err := r.translate(arg)
+			if err != nil {
+				return nil, err
+			}
+			if named == nil {
+				named = map[string]sqlExpr{}
+			}
+			named[name] = translated
+		}
+		args := make([]sqlExpr, len(e.Args))
+		for i, arg := range e.Args {
+			translated, err := r.translate(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = translated
+		}
+		return r.c.callFunction(e.Pos(), e.Name, args, named)
+
+	case syntax.Interpolation:
+		return r.translateInterpolation(e)
+
+	case syntax.Case:
+		return r.translateCase(e)
+
+	case syntax.Pipeline:
+		return r.translatePipe(e)
+
+	case syntax.Assign:
+		return nil, errorf(e.Pos(), "unexpected assignment of '%s'", e.Name)
+	}
+
+	return nil, errorf(expr.Pos(), "unexpected expression %s", expr.String())
+}
+
+// translateBinary builds the SQL for a binary operation with the already
+// translated operands. Division always results in a float, so the dialects
+// which divide integers into an integer have the dividend converted first.
+// With the SafeDivision option, dividing by zero results in null instead.
+func (c *compiler) translateBinary(e syntax.Binary, left, right sqlExpr) (sqlExpr, error) {
+	if e.Op == "??" {
+		return funcExpr{name: "COALESCE", args: []sqlExpr{left, right}, typ: typeOf(left)}, nil
+	}
+	if e.Op == "~=" {
+		return c.regexMatch(e.Pos(), left, right)
+	}
+
+	op, ok := binaryOperators[e.Op]
+	if !ok {
+		return nil, errorf(e.Pos(), "unexpected operator '%s'", e.Op)
+	}
+
+	if interval, ok := right.(intervalExpr); ok && (op == "+" || op == "-") {
+		if added, ok := c.addInterval(left, interval, op == "-"); ok {
+			return added, nil
+		}
+	}
+	if interval, ok := left.(intervalExpr); ok && op == "+" {
+		if added, ok := c.addInterval(right, interval, false); ok {
+			return added, nil
+		}
+	}
+
+	if op == "/" && !c.target.Supports(CapabilityFloatDivision) &&
+		typeOf(left) != syntax.TypeFloat && typeOf(right) != syntax.TypeFloat {
+		left = castExpr{left, syntax.TypeFloat}
+	}
+	if op == "/" && c.opts.SafeDivision {
+		template := "{0} / NULLIF({1}, 0)"
+		if c.target.Supports(CapabilitySafeDivide) {
+			template = "SAFE_DIVIDE({0}, {1})"
+		}
+		return funcExpr{name: "SAFE_DIVIDE", template: template, args: []sqlExpr{left, right}, typ: syntax.TypeFloat}, nil
+	}
+
+	// Comparisons against null use "IS" instead
+	if lit, ok := right.(literalExpr); ok && lit.isNull() {
+		if op == "=" {
+			op = "IS"
+		} else if op == "<>" {
+			op = "IS NOT"
+		}
+	}
+	if binaryPrecedence[op] == precCompare && !c.target.Supports(CapabilityImplicitConversions) {
+		var err error
+		if left, right, err = c.strictComparison(e.Pos(), left, right); err != nil {
+			return nil, err
+		}
+	}
+
+	return binaryExpr{op, left, right}, nil
+}
+
+// translateInterpolation converts an s-string into raw SQL, or an f-string
+// into a concatenation of strings.
+func (r *relation) translateInterpolation(e syntax.Interpolation) (sqlExpr, error) {
+	parts := make([]rawPart, len(e.Parts))
+	for i, part := range e.Parts {
+		if part.Expr == nil {
+			parts[i] = rawPart{text: part.Text}
+			continue
+		}
+
+		expr, err := r.translate(part.Expr)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = rawPart{expr: expr}
+	}
+
+	if e.Kind == 's' {
+		return rawExpr{parts}, nil
+	}
+
+	var concat sqlExpr
+	for _, part := range parts {
+		var item sqlExpr = literalExpr{syntax.TypeString, part.text}
+		if part.expr != nil {
+			item = part.expr
+		}
+
+		if concat == nil {
+			concat = item
+		} else {
+			concat = binaryExpr{"||", concat, item}
+		}
+	}
+	if concat == nil {
+		concat = literalExpr{syntax.TypeString, ""}
+	}
+	return concat, nil
+}
+
+// translateCase converts a case expression into a CASE. An arm with the
+// condition "true" becomes the ELSE, which must be the last arm. The values of
+// each arm must have compatible types.
+func (r *relation) translateCase(e syntax.Case) (sqlExpr, error) {
+	expr := caseExpr{}
+	for i, arm := range e.Arms {
+		value, err := r.translate(arm.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		typ := typeOf(value)
+		if !compatibleTypes(expr.typ, typ) {
+			return nil, errorf(arm.Value.Pos(), "'case' arm yields %s but the earlier arms yield %s", typ, expr.typ)
+		}
+		if expr.typ == syntax.TypeUnknown || typ == syntax.TypeFloat {
+			expr.typ = typ
+		}
+
+		if lit, ok := arm.Condition.(syntax.Literal); ok && lit.Type == syntax.TypeBoolean && lit.Value == "true" {
+			if i != len(e.Arms)-1 {
+				return nil, errorf(e.Arms[i+1].Condition.Pos(), "'case' arm follows the 'true' arm so is never used")
+			}
+			expr.els = value
+			continue
+		}
+
+		cond, err := r.translate(arm.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if typ := typeOf(cond); typ != syntax.TypeBoolean && typ != syntax.TypeUnknown {
+			return nil, errorf(arm.Condition.Pos(), "'case' condition must be boolean but found %s", typ)
+		}
+		expr.whens = append(expr.whens, caseWhen{cond, value})
+	}
+
+	// Only a "true" arm is always that value
+	if len(expr.whens) == 0 {
+		return expr.els, nil
+	}
+	return expr, nil
+}
+
+// resolve finds the expression for a name. Columns computed earlier within
+// the pipeline are inlined, while unknown names are assumed to be columns of
+// the source relations.
+func (r *relation) resolve(ident syntax.Ident) (sqlExpr, error) {
+	if dot := strings.LastIndexByte(ident.Name, '.'); dot >= 0 {
+		return columnRef{ident.Name[:dot], ident.Name[dot+1:]}, nil
+	}
+
+	if col := r.lookup(ident.Name); col != nil {
+		return col.expr, nil
+	}
+
+	// Functions without any arguments can be called with just their name
+	if fn, ok := r.c.functions[ident.Name]; ok {
+		return r.callDeclared(fn, syntax.Call{Position: ident.Position, Name: ident.Name})
+	}
+	if fn, ok := functions[ident.Name]; ok && fn.minArgs == 0 {
+		return r.c.callFunction(ident.Pos(), ident.Name, nil, nil)
+	}
+
+	if !r.hasStar() {
+		return nil, errorf(ident.Pos(), "unknown name '%s'", ident.Name)
+	}
+	return columnRef{name: ident.Name}, nil
+}
+
+// itemName returns the name given to a column by the PRQL expression, which is
+// the assigned name, or the name of a referenced column. Other expressions are
+// unnamed.
+func itemName(expr syntax.Expr) (string, syntax.Expr) {
+	switch e := expr.(type) {
+	case syntax.Assign:
+		return e.Name, e.Value
+	case syntax.Ident:
+		parts := e.Parts()
+		return parts[len(parts)-1], e
+	}
+	return "", expr
+}
+
+// listItems returns the items of a list, or the expression itself when it is
+// not a list.
+func listItems(expr syntax.Expr) []syntax.Expr {
+	if list, ok := expr.(syntax.List); ok {
+		return list.Items
+	}
+	return []syntax.Expr{expr}
+}
+
+// translateColumns translates the items of a list (or single expression) into
+// new columns. Each item may reference the names of the items before it.
+func (r *relation) translateColumns(expr syntax.Expr) ([]*column, error) {
+	scope := r.columns
+	defer func() {
+		r.columns = scope
+	}()
+
+	items := listItems(expr)
+	columns := make([]*column, 0, len(items))
+	for _, item := range items {
+		name, value := itemName(item)
+		translated, err := r.translate(value)
+		if err != nil {
+			return nil, err
+		}
+
+		col := &column{name: name, expr: translated}
+		columns = append(columns, col)
+		r.columns = append(r.columns[:len(r.columns):len(r.columns)], col)
+	}
+	return columns, nil
+}
+
+// castTypes holds the types which values may be cast into by their PRQL name
+var castTypes = map[string]syntax.Type{
+	"bool":      syntax.TypeBoolean,
+	"boolean":   syntax.TypeBoolean,
+	"int":       syntax.TypeInteger,
+	"integer":   syntax.TypeInteger,
+	"float":     syntax.TypeFloat,
+	"text":      syntax.TypeString,
+	"string":    syntax.TypeString,
+	"date":      syntax.TypeDate,
+	"time":      syntax.TypeTime,
+	"timestamp": syntax.TypeTimestamp,
+}
+
+// translateCast converts a value into another type, "as {type} {value}",
+// which is usually piped into as "value | as int".
+func (r *relation) translateCast(call syntax.Call) (sqlExpr, error) {
+	if len(call.Args) != 2 || len(call.Named) > 0 {
+		return nil, errorf(call.Pos(), "'as' expects a type and a value")
+	}
+
+	ident, ok := call.Args[0].(syntax.Ident)
+	typ, known := castTypes[ident.Name]
+	if !ok || !known {
+		return nil, errorf(call.Args[0].Pos(), "'as' expects a type but found %s", call.Args[0].String())
+	}
+
+	value, err := r.translate(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
+	return castExpr{value, typ}, nil
+}
@@ -0,0 +1,94 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileCase(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "else arm",
+			source: `
+from t
+derive tier = case [amount > 1000 => "gold", amount > 100 => "silver", true => "bronze"]`,
+			expected: `
+SELECT t.*, CASE WHEN amount > 1000 THEN 'gold' WHEN amount > 100 THEN 'silver' ELSE 'bronze' END AS tier
+FROM t`,
+		},
+		{
+			name: "without else",
+			source: `
+from t
+select [x = case [
+  a == null => 0,
+  a > 1 => 1.5,
+]]`,
+			expected: `
+SELECT CASE WHEN a IS NULL THEN 0 WHEN a > 1 THEN 1.5 END AS x
+FROM t`,
+		},
+		{
+			name: "only true arm",
+			source: `
+from t
+select [x = case [true => 1] + 1]`,
+			expected: `
+SELECT 1 + 1 AS x
+FROM t`,
+		},
+	})
+}
+
+func TestCompileCaseErrors(t *testing.T) {
+	compileError(t, `from t
+derive y = case [b => 1, true => "x"]`, "2:34: 'case' arm yields string but the earlier arms yield integer")
+	compileError(t, "from t\nderive x = case [a => 1, true => 2, b => 3]", "2:37: 'case' arm follows the 'true' arm so is never used")
+	compileError(t, "from t\nderive x = case [5 => 1]", "2:18: 'case' condition must be boolean but found integer")
+}
+
+func TestCompileCast(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "pipe",
+			source: `
+from t
+derive [a = (b | as int), c = (d + 1 | as text)]`,
+			expected: `
+SELECT t.*, CAST(b AS INTEGER) AS a, CAST(d + 1 AS VARCHAR) AS c
+FROM t`,
+		},
+		{
+			name: "mysql",
+			source: `prql dialect:mysql
+from t
+select [a = as int b, c = as text d, e = as timestamp f]`,
+			expected: `
+SELECT CAST(b AS SIGNED) AS a, CAST(d AS CHAR) AS c, CAST(f AS DATETIME) AS e
+FROM t`,
+		},
+	})
+
+	compileError(t, "from t\nderive [a = (b | as money)]", "2:21: 'as' expects a type but found money")
+	compileError(t, "from t\nderive [a = as int]", "2:13: 'as' expects a type and a value")
+}
+
+func TestCompileOperatorParenthesis(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "same associative operator",
+			source: `
+from t
+select [a = x + (y + z), b = x * (y * z), c = x and (y and z)]`,
+			expected: `
+SELECT x + y + z AS a, x * y * z AS b, x AND y AND z AS c
+FROM t`,
+		},
+		{
+			name: "same precedence",
+			source: `
+from t
+select [a = x * (y % 2), b = x * (y / z), c = x + (y - z), d = x - (y + z)]`,
+			expected: `
+SELECT x * (y % 2) AS a, x * (y / z) AS b, x + (y - z) AS c, x - (y + z) AS d
+FROM t`,
+		},
+	})
+}
@@ -0,0 +1,524 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// function describes a built-in function which may be called within PRQL
+// expressions.
+type function struct {
+	// sql is the name of the SQL function, or a template where "{0}", "{1}"
+	// and so on are replaced by the arguments
+	sql string
+
+	minArgs int
+	maxArgs int
+
+	// params holds the kind of value accepted by each argument, in order.
+	// Arguments without a kind accept any value.
+	params []paramKind
+
+	// typ is the returned type, TypeUnknown uses the type of the value argument
+	typ syntax.Type
+
+	// value is the index of the argument which the function is applied to
+	value int
+
+	// star renders "*" as the argument when none are given
+	star bool
+
+	aggregate bool
+	window    bool
+
+	// framed marks window functions which are computed over the window frame
+	framed bool
+
+	// like is the LIKE pattern used instead of the SQL when the first argument
+	// is a string literal, where "%s" is replaced by the escaped literal. The
+	// SQL is kept when the case must match but "LIKE" would ignore it.
+	like string
+
+	// unit marks functions of a date unit, given by the first argument. The
+	// unit replaces "{unit}" within the SQL, or "{UNIT}" in upper case.
+	unit bool
+
+	// format marks functions formatting dates, with the first argument a
+	// strftime format which is translated for the dialect
+	format bool
+}
+
+// paramKind is the kind of value accepted by an argument of a function.
+type paramKind byte
+
+const (
+	anyParam paramKind = iota
+	numericParam
+	booleanParam
+	textParam
+	temporalParam
+)
+
+// holds paramKind -> string mapping
+var paramKindStringMap = map[paramKind]string{
+	anyParam:      "any",
+	numericParam:  "numeric",
+	booleanParam:  "boolean",
+	textParam:     "text",
+	temporalParam: "a date or time",
+}
+
+// String returns the string representation of the paramKind
+func (k paramKind) String() string {
+	return paramKindStringMap[k]
+}
+
+// accepts returns true if a value of the type may be given as the argument.
+// Values of unknown type are always accepted.
+func (k paramKind) accepts(typ syntax.Type) bool {
+	switch {
+	case k == anyParam || typ == syntax.TypeUnknown:
+		return true
+	case k == numericParam:
+		return typ == syntax.TypeInteger || typ == syntax.TypeFloat || typ == syntax.TypeScalar
+	case k == booleanParam:
+		return typ == syntax.TypeBoolean
+	case k == textParam:
+		return typ == syntax.TypeString
+	case k == temporalParam:
+		return typ == syntax.TypeDate || typ == syntax.TypeTime || typ == syntax.TypeTimestamp
+	}
+	return false
+}
+
+// functions holds the built-in functions by their PRQL name. The SQL is that
+// of the generic dialect, which other dialects replace within stdlib. An empty
+// string marks a function without a standard form, such as "date.diff", which
+// is only supported by the dialects giving their own.
+var functions = map[string]function{
+	"sum":              {sql: "SUM", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}, aggregate: true},
+	"average":          {sql: "AVG", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}, typ: syntax.TypeFloat, aggregate: true},
+	"stddev":           {sql: "STDDEV_SAMP", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}, typ: syntax.TypeFloat, aggregate: true},
+	"min":              {sql: "MIN", minArgs: 1, maxArgs: 1, aggregate: true},
+	"max":              {sql: "MAX", minArgs: 1, maxArgs: 1, aggregate: true},
+	"count":            {sql: "COUNT", minArgs: 0, maxArgs: 1, typ: syntax.TypeInteger, star: true, aggregate: true},
+	"count_distinct":   {sql: "COUNT(DISTINCT {0})", minArgs: 1, maxArgs: 1, typ: syntax.TypeInteger, aggregate: true},
+	"count_if":         {sql: "COUNT", minArgs: 1, maxArgs: 1, params: []paramKind{booleanParam}, typ: syntax.TypeInteger, aggregate: true},
+	"any":              {sql: "BOOL_OR", minArgs: 1, maxArgs: 1, params: []paramKind{booleanParam}, typ: syntax.TypeBoolean, aggregate: true},
+	"every":            {sql: "BOOL_AND", minArgs: 1, maxArgs: 1, params: []paramKind{booleanParam}, typ: syntax.TypeBoolean, aggregate: true},
+	"text.lower":       {sql: "LOWER", minArgs: 1, maxArgs: 1, params: []paramKind{textParam}, typ: syntax.TypeString},
+	"text.upper":       {sql: "UPPER", minArgs: 1, maxArgs: 1, params: []paramKind{textParam}, typ: syntax.TypeString},
+	"text.trim":        {sql: "TRIM", minArgs: 1, maxArgs: 1, params: []paramKind{textParam}, typ: syntax.TypeString},
+	"text.length":      {sql: "LENGTH", minArgs: 1, maxArgs: 1, params: []paramKind{textParam}, typ: syntax.TypeInteger},
+	"text.contains":    {sql: "POSITION({0} IN {1}) > 0", minArgs: 2, maxArgs: 2, params: []paramKind{textParam, textParam}, typ: syntax.TypeBoolean, like: "%%%s%%"},
+	"text.starts_with": {sql: "SUBSTR({1}, 1, LENGTH({0})) = {0}", minArgs: 2, maxArgs: 2, params: []paramKind{textParam, textParam}, typ: syntax.TypeBoolean, like: "%s%%"},
+	"text.replace":     {sql: "REPLACE({2}, {0}, {1})", minArgs: 3, maxArgs: 3, params: []paramKind{textParam, textParam, textParam}, typ: syntax.TypeString},
+	"math.round":       {sql: "ROUND({1}, {0})", minArgs: 2, maxArgs: 2, params: []paramKind{numericParam, numericParam}, value: 1},
+	"math.floor":       {sql: "FLOOR", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}, typ: syntax.TypeInteger},
+	"math.ceil":        {sql: "CEIL", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}, typ: syntax.TypeInteger},
+	"math.abs":         {sql: "ABS", minArgs: 1, maxArgs: 1, params: []paramKind{numericParam}},
+	"math.pow":         {sql: "POWER({1}, {0})", minArgs: 2, maxArgs: 2, params: []paramKind{numericParam, numericParam}, typ: syntax.TypeFloat},
+	"date.trunc":       {sql: "DATE_TRUNC('{unit}', {1})", minArgs: 2, maxArgs: 2, params: []paramKind{textParam, temporalParam}, value: 1, unit: true},
+	"date.extract":     {sql: "EXTRACT({UNIT} FROM {1})", minArgs: 2, maxArgs: 2, params: []paramKind{textParam, temporalParam}, typ: syntax.TypeInteger, unit: true},
+	"date.to_text":     {sql: "TO_CHAR({1}, {0})", minArgs: 2, maxArgs: 2, params: []paramKind{textParam, temporalParam}, typ: syntax.TypeString, format: true},
+	"date.diff":        {sql: "", minArgs: 3, maxArgs: 3, params: []paramKind{textParam, temporalParam, temporalParam}, typ: syntax.TypeInteger, unit: true},
+	"row_number":       {sql: "ROW_NUMBER", typ: syntax.TypeInteger, window: true},
+	"rank":             {sql: "RANK", typ: syntax.TypeInteger, window: true},
+	"dense_rank":       {sql: "DENSE_RANK", typ: syntax.TypeInteger, window: true},
+	"lag":              {sql: "LAG", minArgs: 1, maxArgs: 2, window: true},
+	"lead":             {sql: "LEAD", minArgs: 1, maxArgs: 2, window: true},
+	"first_value":      {sql: "FIRST_VALUE", minArgs: 1, maxArgs: 1, window: true, framed: true},
+	"last_value":       {sql: "LAST_VALUE", minArgs: 1, maxArgs: 1, window: true, framed: true},
+}
+
+// stdlib holds the SQL of the built-in functions which differ from the generic
+// dialect, by dialect and then PRQL name. An empty string marks a function
+// which the dialect does not support. Functions of a date unit may be given
+// for a single unit, as "{name}:{unit}", which is used before the SQL for any
+// unit. Likewise, counting every row may be given as "count:*" where it is
+// not "(*)".
+//
+// Rounding is half away from zero, and the dialects which round floats to
+// even are given their own SQL. Aggregates must remain a single call, as
+// windows place "OVER" after them.
+var stdlib = map[syntax.Dialect]map[string]string{
+	syntax.DialectANSI: {
+		"~=":               "{0} LIKE_REGEX {1}",
+		"text.length":      "CHAR_LENGTH",
+		"text.starts_with": "SUBSTRING({1} FROM 1 FOR CHAR_LENGTH({0})) = {0}",
+	},
+	syntax.DialectBigQuery: {
+		"~=":                "REGEXP_CONTAINS({0}, {1})",
+		"count_if":          "COUNTIF",
+		"any":               "LOGICAL_OR",
+		"every":             "LOGICAL_AND",
+		"text.contains":     "STRPOS({1}, {0}) > 0",
+		"date.trunc":        "DATE_TRUNC({1}, {UNIT})",
+		"date.trunc:week":   "DATE_TRUNC({1}, ISOWEEK)",
+		"date.extract:week": "EXTRACT(ISOWEEK FROM {1})",
+		"date.to_text":      "FORMAT_TIMESTAMP({0}, {1})",
+		"date.diff":         "DATE_DIFF({2}, {1}, {UNIT})",
+		"date.diff:week":    "DATE_DIFF({2}, {1}, ISOWEEK)",
+	},
+	// ClickHouse functions are named in camel case, and "LENGTH" counts bytes
+	// rather than characters
+	syntax.DialectClickHouse: {
+		"~=":                   "match({0}, {1})",
+		"sum":                  "sum",
+		"min":                  "min",
+		"max":                  "max",
+		"count":                "count",
+		"count:*":              "count()",
+		"average":              "avg",
+		"stddev":               "stddevSamp",
+		"count_distinct":       "uniqExact",
+		"count_if":             "countIf",
+		"any":                  "max",
+		"every":                "min",
+		"text.lower":           "lowerUTF8",
+		"text.upper":           "upperUTF8",
+		"text.trim":            "trimBoth",
+		"text.length":          "lengthUTF8",
+		"text.contains":        "positionUTF8({1}, {0}) > 0",
+		"text.starts_with":     "startsWith({1}, {0})",
+		"text.replace":         "replaceAll({2}, {0}, {1})",
+		"math.round":           "sign({1}) * floor(abs({1}) * pow(10, {0}) + 0.5) / pow(10, {0})",
+		"math.floor":           "floor",
+		"math.ceil":            "ceil",
+		"math.abs":             "abs",
+		"math.pow":             "pow({1}, {0})",
+		"date.trunc:year":      "toStartOfYear({1})",
+		"date.trunc:quarter":   "toStartOfQuarter({1})",
+		"date.trunc:month":     "toStartOfMonth({1})",
+		"date.trunc:week":      "toMonday({1})",
+		"date.trunc:day":       "toStartOfDay({1})",
+		"date.trunc:hour":      "toStartOfHour({1})",
+		"date.trunc:minute":    "toStartOfMinute({1})",
+		"date.trunc:second":    "toStartOfSecond({1})",
+		"date.extract:year":    "toYear({1})",
+		"date.extract:quarter": "toQuarter({1})",
+		"date.extract:month":   "toMonth({1})",
+		"date.extract:week":    "toISOWeek({1})",
+		"date.extract:day":     "toDayOfMonth({1})",
+		"date.extract:hour":    "toHour({1})",
+		"date.extract:minute":  "toMinute({1})",
+		"date.extract:second":  "toSecond({1})",
+		"date.to_text":         "formatDateTime({1}, {0})",
+		"date.diff":            "dateDiff('{unit}', {1}, {2})",
+	},
+	// Hive truncates only to years, quarters or months
+	syntax.DialectHive: {
+		"~=":                 "{0} RLIKE {1}",
+		"any":                "MAX",
+		"every":              "MIN",
+		"text.contains":      "INSTR({1}, {0}) > 0",
+		"date.trunc":         "",
+		"date.trunc:year":    "TRUNC({1}, 'YEAR')",
+		"date.trunc:quarter": "TRUNC({1}, 'QUARTER')",
+		"date.trunc:month":   "TRUNC({1}, 'MONTH')",
+		"date.trunc:day":     "TO_DATE({1})",
+		"date.extract:week":  "WEEKOFYEAR({1})",
+		"date.to_text":       "DATE_FORMAT({1}, {0})",
+		"date.diff:year":     "CAST(MONTHS_BETWEEN({2}, {1}) / 12 AS INT)",
+		"date.diff:quarter":  "CAST(MONTHS_BETWEEN({2}, {1}) / 3 AS INT)",
+		"date.diff:month":    "CAST(MONTHS_BETWEEN({2}, {1}) AS INT)",
+		"date.diff:week":     "CAST(DATEDIFF({2}, {1}) / 7 AS INT)",
+		"date.diff:day":      "DATEDIFF({2}, {1})",
+		"date.diff:hour":     "CAST((UNIX_TIMESTAMP({2}) - UNIX_TIMESTAMP({1})) / 3600 AS INT)",
+		"date.diff:minute":   "CAST((UNIX_TIMESTAMP({2}) - UNIX_TIMESTAMP({1})) / 60 AS INT)",
+		"date.diff:second":   "UNIX_TIMESTAMP({2}) - UNIX_TIMESTAMP({1})",
+	},
+	// Without a boolean type the conditions are counted as 1 or 0. Text is
+	// matched within a binary collation, as the default ignores case.
+	syntax.DialectMSSQL: {
+		"stddev":            "STDEV",
+		"any":               "MAX(CAST({0} AS INT))",
+		"every":             "MIN(CAST({0} AS INT))",
+		"text.length":       "LEN",
+		"text.trim":         "LTRIM(RTRIM({0}))",
+		"text.contains":     "CHARINDEX({0}, {1} COLLATE Latin1_General_BIN2) > 0",
+		"text.starts_with":  "SUBSTRING({1}, 1, LEN({0})) = {0} COLLATE Latin1_General_BIN2",
+		"math.ceil":         "CEILING",
+		"math.pow":          "POWER(CAST({1} AS FLOAT), {0})",
+		"date.trunc":        "DATETRUNC({unit}, {1})",
+		"date.trunc:week":   "DATETRUNC(iso_week, {1})",
+		"date.extract":      "DATEPART({unit}, {1})",
+		"date.extract:week": "DATEPART(iso_week, {1})",
+		"date.to_text":      "FORMAT({1}, {0})",
+		"date.diff":         "DATEDIFF({unit}, {1}, {2})",
+	},
+	// MySQL compares text ignoring case, so matches it as binary strings
+	syntax.DialectMYSQL: {
+		"<=>":                "{0} <=> {1}",
+		"any":                "MAX",
+		"every":              "MIN",
+		"text.length":        "CHAR_LENGTH",
+		"text.contains":      "LOCATE(CAST({0} AS BINARY), CAST({1} AS BINARY)) > 0",
+		"text.starts_with":   "LOCATE(CAST({0} AS BINARY), CAST({1} AS BINARY)) = 1",
+		"math.round":         "SIGN({1}) * FLOOR(ABS({1}) * POWER(10, {0}) + 0.5) / POWER(10, {0})",
+		"date.trunc:year":    "CAST(DATE_FORMAT({1}, '%Y-01-01') AS DATE)",
+		"date.trunc:quarter": "MAKEDATE(YEAR({1}), 1) + INTERVAL QUARTER({1}) - 1 QUARTER",
+		"date.trunc:month":   "CAST(DATE_FORMAT({1}, '%Y-%m-01') AS DATE)",
+		"date.trunc:week":    "DATE_SUB(DATE({1}), INTERVAL WEEKDAY({1}) DAY)",
+		"date.trunc:day":     "DATE({1})",
+		"date.trunc:hour":    "CAST(DATE_FORMAT({1}, '%Y-%m-%d %H:00:00') AS DATETIME)",
+		"date.trunc:minute":  "CAST(DATE_FORMAT({1}, '%Y-%m-%d %H:%i:00') AS DATETIME)",
+		"date.trunc:second":  "CAST(DATE_FORMAT({1}, '%Y-%m-%d %H:%i:%s') AS DATETIME)",
+		"date.extract:week":  "WEEK({1}, 3)",
+		"date.to_text":       "DATE_FORMAT({1}, {0})",
+		"date.diff":          "TIMESTAMPDIFF({UNIT}, {1}, {2})",
+	},
+	// Postgres subtracts dates into a number of days
+	syntax.DialectPostgres: {
+		"~=":             "{0} ~ {1}",
+		"text.contains":  "STRPOS({1}, {0}) > 0",
+		"math.round":     "ROUND({1}::NUMERIC, {0})",
+		"date.diff:week": "({2}::DATE - {1}::DATE) / 7",
+		"date.diff:day":  "{2}::DATE - {1}::DATE",
+	},
+	// SQLite keeps dates as text, which it's date functions format and parse
+	syntax.DialectSQLite: {
+		"stddev":               "",
+		"any":                  "MAX",
+		"every":                "MIN",
+		"text.contains":        "INSTR({1}, {0}) > 0",
+		"date.trunc:year":      "DATE({1}, 'start of year')",
+		"date.trunc:quarter":   "DATE({1}, 'start of month', '-' || ((CAST(STRFTIME('%m', {1}) AS INTEGER) - 1) % 3) || ' months')",
+		"date.trunc:month":     "DATE({1}, 'start of month')",
+		"date.trunc:week":      "DATE({1}, '-6 days', 'weekday 1')",
+		"date.trunc:day":       "DATE({1})",
+		"date.trunc:hour":      "STRFTIME('%Y-%m-%d %H:00:00', {1})",
+		"date.trunc:minute":    "STRFTIME('%Y-%m-%d %H:%M:00', {1})",
+		"date.trunc:second":    "STRFTIME('%Y-%m-%d %H:%M:%S', {1})",
+		"date.extract:year":    "CAST(STRFTIME('%Y', {1}) AS INTEGER)",
+		"date.extract:quarter": "(CAST(STRFTIME('%m', {1}) AS INTEGER) + 2) / 3",
+		"date.extract:month":   "CAST(STRFTIME('%m', {1}) AS INTEGER)",
+		"date.extract:week":    "",
+		"date.extract:day":     "CAST(STRFTIME('%d', {1}) AS INTEGER)",
+		"date.extract:hour":    "CAST(STRFTIME('%H', {1}) AS INTEGER)",
+		"date.extract:minute":  "CAST(STRFTIME('%M', {1}) AS INTEGER)",
+		"date.extract:second":  "CAST(STRFTIME('%S', {1}) AS INTEGER)",
+		"date.to_text":         "STRFTIME({0}, {1})",
+		"date.diff:week":       "CAST((JULIANDAY({2}) - JULIANDAY({1})) / 7 AS INTEGER)",
+		"date.diff:day":        "CAST(JULIANDAY({2}) - JULIANDAY({1}) AS INTEGER)",
+		"date.diff:hour":       "(STRFTIME('%s', {2}) - STRFTIME('%s', {1})) / 3600",
+		"date.diff:minute":     "(STRFTIME('%s', {2}) - STRFTIME('%s', {1})) / 60",
+		"date.diff:second":     "STRFTIME('%s', {2}) - STRFTIME('%s', {1})",
+	},
+	// Snowflake's "REGEXP_LIKE" must match the whole text, so it finds the
+	// position of a match instead
+	syntax.DialectSnowflake: {
+		"~=":                "REGEXP_INSTR({0}, {1}) > 0",
+		"case":              "IFF({0}, {1}, {2})",
+		"count_if":          "COUNT_IF",
+		"any":               "BOOLOR_AGG",
+		"every":             "BOOLAND_AGG",
+		"date.extract:week": "WEEKISO({1})",
+		"date.diff":         "DATEDIFF({unit}, {1}, {2})",
+	},
+}
+
+// lookupFunction returns the built-in function of the PRQL name, using the SQL
+// of the dialect for the unit, if any.
+func lookupFunction(dialect Dialect, name string, unit string) (function, bool) {
+	fn, ok := functions[name]
+	if !ok {
+		return fn, false
+	}
+	if sql, ok := dialect.Function(name, unit); ok {
+		fn.sql = sql
+	}
+
+	fn.sql = strings.ReplaceAll(fn.sql, "{unit}", unit)
+	fn.sql = strings.ReplaceAll(fn.sql, "{UNIT}", strings.ToUpper(unit))
+	return fn, true
+}
+
+// acceptsNamed returns true if the built-in function has the named parameter.
+// Aggregate functions accept the "filter" of the rows to include, and those
+// matching a LIKE pattern accept "ignore_case". Unknown functions accept any,
+// so that they are reported as unknown instead.
+func acceptsNamed(name, param string) bool {
+	fn, ok := functions[name]
+	if !ok {
+		return true
+	}
+	switch param {
+	case "filter":
+		return fn.aggregate
+	case "ignore_case":
+		return fn.like != ""
+	}
+	return false
+}
+
+// callFunction builds the expression for calling a built-in function with the
+// already translated arguments, and any named arguments accepted by
+// acceptsNamed.
+func (c *compiler) callFunction(pos syntax.Position, name string, args []sqlExpr, named map[string]sqlExpr) (sqlExpr, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, errorf(pos, "unknown function '%s'", name)
+	}
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		if fn.minArgs == fn.maxArgs {
+			return nil, errorf(pos, "function '%s' expects %d arguments but received %d", name, fn.minArgs, len(args))
+		}
+		return nil, errorf(pos, "function '%s' expects %d to %d arguments but received %d", name, fn.minArgs, fn.maxArgs, len(args))
+	}
+	for i, kind := range fn.params {
+		if i < len(args) && !kind.accepts(typeOf(args[i])) {
+			return nil, errorf(pos, "function '%s' expects argument %d to be %s but found %s", name, i+1, kind, typeOf(args[i]))
+		}
+	}
+
+	if fn.window && !c.target.Supports(CapabilityWindows) {
+		return nil, c.unsupported(pos, "window function '"+name+"'")
+	}
+
+	// Counting the rows matching a condition is a filtered count, unless the
+	// dialect has it's own function for it
+	_, countsIf := c.target.Function("count_if", "")
+	switch {
+	case name == "count_if" && !countsIf:
+		return c.callFunction(pos, "count", nil, map[string]sqlExpr{"filter": and(args[0], named["filter"])})
+	case name == "count" && len(args) == 0 && named["filter"] != nil && booleanParam.accepts(typeOf(named["filter"])) &&
+		countsIf && !c.target.Supports(CapabilityAggregateFilter):
+		return c.callFunction(pos, "count_if", []sqlExpr{named["filter"]}, nil)
+	}
+
+	var unit string
+	if fn.unit {
+		var err error
+		if unit, err = dateUnit(pos, name, args[0]); err != nil {
+			return nil, err
+		}
+	}
+	fn, _ = lookupFunction(c.target, name, unit)
+	if fn.star && len(args) == 0 {
+		if sql, ok := c.target.Function(name, "*"); ok {
+			fn.sql, fn.star = sql, false
+		}
+	}
+	if fn.sql == "" && unit != "" {
+		return nil, c.unsupported(pos, "function '"+name+"' by "+unit)
+	} else if fn.sql == "" {
+		return nil, c.unsupported(pos, "function '"+name+"'")
+	}
+
+	if fn.format {
+		lit, ok := args[0].(literalExpr)
+		if !ok || lit.typ != syntax.TypeString {
+			return nil, errorf(pos, "function '%s' expects the format to be a string literal", name)
+		}
+		format, unknown := c.target.DateFormat(lit.value)
+		if unknown != "" {
+			return nil, c.unsupported(pos, "date format '"+unknown+"'")
+		}
+		args = append([]sqlExpr{literalExpr{syntax.TypeString, format}}, args[1:]...)
+	}
+
+	if fn.like != "" {
+		ignoreCase, err := booleanOption(pos, name, "ignore_case", named)
+		if err != nil {
+			return nil, err
+		}
+		lit, ok := args[0].(literalExpr)
+		if ok && lit.typ == syntax.TypeString && (ignoreCase || c.target.Supports(CapabilityCaseSensitiveLike)) {
+			return c.like(args[len(args)-1], fn.like, lit.value, ignoreCase), nil
+		}
+		if ignoreCase {
+			args = []sqlExpr{lower(args[0]), lower(args[len(args)-1])}
+		}
+	}
+
+	// Rows excluded by the filter are emulated as nulls, which aggregates
+	// ignore
+	filter := named["filter"]
+	if filter != nil && !booleanParam.accepts(typeOf(filter)) {
+		return nil, errorf(pos, "function '%s' expects the filter to be boolean but found %s", name, typeOf(filter))
+	}
+	if filter != nil && !c.target.Supports(CapabilityAggregateFilter) {
+		if len(args) == 0 {
+			args = []sqlExpr{caseExpr{whens: []caseWhen{{filter, literalExpr{syntax.TypeInteger, "1"}}}, typ: syntax.TypeInteger}}
+		} else {
+			args = append([]sqlExpr(nil), args...)
+			args[fn.value] = caseExpr{whens: []caseWhen{{filter, args[fn.value]}}, typ: typeOf(args[fn.value])}
+		}
+		filter = nil
+	}
+
+	typ := fn.typ
+	if typ == syntax.TypeUnknown && len(args) > fn.value {
+		typ = typeOf(args[fn.value])
+	}
+
+	expr := funcExpr{
+		name:      fn.sql,
+		args:      args,
+		typ:       typ,
+		star:      fn.star && len(args) == 0,
+		aggregate: fn.aggregate,
+		window:    fn.window,
+		framed:    fn.framed,
+		filter:    filter,
+	}
+	if strings.ContainsAny(fn.sql, "{(") {
+		expr.name, expr.template = "", fn.sql
+	}
+	return expr, nil
+}
+
+// like builds the match of the value against the LIKE pattern, with the
+// literal text escaped in place of the "%s". Matches ignoring case use "ILIKE"
+// where it is supported, otherwise both sides are lower case.
+func (c *compiler) like(value sqlExpr, pattern, text string, ignoreCase bool) sqlExpr {
+	if ignoreCase && !c.target.Supports(CapabilityILike) {
+		value, text, ignoreCase = lower(value), strings.ToLower(text), false
+	}
+	return likeExpr{
+		value:       value,
+		pattern:     fmt.Sprintf(pattern, escapeLike(text, c.target.Supports(CapabilityLikeSets))),
+		insensitive: ignoreCase,
+	}
+}
+
+// lower converts the text to lower case.
+func lower(text sqlExpr) sqlExpr {
+	return funcExpr{name: "LOWER", args: []sqlExpr{text}, typ: syntax.TypeString}
+}
+
+// booleanOption returns the value of a named argument which must be a boolean
+// literal, or false when it is not given.
+func booleanOption(pos syntax.Position, name, param string, named map[string]sqlExpr) (bool, error) {
+	value, ok := named[param]
+	if !ok {
+		return false, nil
+	}
+	lit, ok := value.(literalExpr)
+	if !ok || lit.typ != syntax.TypeBoolean {
+		return false, errorf(pos, "function '%s' expects %s to be true or false", name, param)
+	}
+	return lit.value == "true", nil
+}
+
+// escapeLike escapes the characters of the text which have a meaning within a
+// LIKE pattern, using a backslash. Patterns may also match sets of characters
+// within square brackets, such as those of SQL Server.
+func escapeLike(text string, sets bool) string {
+	special := `\%_`
+	if sets {
+		special += "["
+	}
+
+	var str strings.Builder
+	for _, char := range text {
+		if strings.ContainsRune(special, char) {
+			str.WriteByte('\\')
+		}
+		str.WriteRune(char)
+	}
+	return str.String()
+}
@@ -0,0 +1,247 @@
+package compiler_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+)
+
+func TestCompileAggregateFunctions(t *testing.T) {
+	const pipeline = `
+from t
+group a (aggregate [s = stddev x, c = count_distinct y, any_ok = any ok, all_ok = every ok])`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "generic",
+			source: pipeline,
+			expected: `
+SELECT a, STDDEV_SAMP(x) AS s, COUNT(DISTINCT y) AS c, BOOL_OR(ok) AS any_ok, BOOL_AND(ok) AS all_ok
+FROM t
+GROUP BY a`,
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql" + pipeline,
+			expected: `
+SELECT a, STDEV(x) AS s, COUNT(DISTINCT y) AS c, MAX(CAST(ok AS INT)) AS any_ok, MIN(CAST(ok AS INT)) AS all_ok
+FROM t
+GROUP BY a`,
+		},
+		{
+			name:   "bigquery",
+			source: "prql dialect:bigquery" + pipeline,
+			expected: `
+SELECT a, STDDEV_SAMP(x) AS s, COUNT(DISTINCT y) AS c, LOGICAL_OR(ok) AS any_ok, LOGICAL_AND(ok) AS all_ok
+FROM t
+GROUP BY a`,
+		},
+		{
+			name:   "snowflake",
+			source: "prql dialect:snowflake" + pipeline,
+			expected: `
+SELECT a, STDDEV_SAMP(x) AS s, COUNT(DISTINCT y) AS c, BOOLOR_AGG(ok) AS any_ok, BOOLAND_AGG(ok) AS all_ok
+FROM t
+GROUP BY a`,
+		},
+		{
+			name: "template within window",
+			source: `
+from t
+derive [c = count_distinct y]`,
+			expected: `
+SELECT t.*, COUNT(DISTINCT y) OVER () AS c
+FROM t`,
+		},
+	})
+}
+
+func TestCompileTextFunctions(t *testing.T) {
+	const pipeline = `
+from t
+filter (text.contains "50%_off" name) and (name | text.starts_with prefix) or (text.contains code name)
+derive [l = text.length (text.lower name), r = (name | text.trim | text.replace "a" "b")]`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "generic",
+			source: pipeline,
+			expected: `
+SELECT t.*, LENGTH(LOWER(name)) AS l, REPLACE(TRIM(name), 'a', 'b') AS r
+FROM t
+WHERE name LIKE '%50\%\_off%' ESCAPE '\' AND SUBSTR(name, 1, LENGTH(prefix)) = prefix OR POSITION(code IN name) > 0`,
+		},
+		{
+			name:   "postgres",
+			source: "prql dialect:postgres" + pipeline,
+			expected: `
+SELECT t.*, LENGTH(LOWER(name)) AS l, REPLACE(TRIM(name), 'a', 'b') AS r
+FROM t
+WHERE name LIKE '%50\%\_off%' AND SUBSTR(name, 1, LENGTH(prefix)) = prefix OR STRPOS(name, code) > 0`,
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql" + pipeline,
+			expected: `
+SELECT t.*, LEN(LOWER(name)) AS l, REPLACE(LTRIM(RTRIM(name)), 'a', 'b') AS r
+FROM t
+WHERE CHARINDEX('50%_off', name COLLATE Latin1_General_BIN2) > 0 AND SUBSTRING(name, 1, LEN(prefix)) = prefix COLLATE Latin1_General_BIN2 OR CHARINDEX(code, name COLLATE Latin1_General_BIN2) > 0`,
+		},
+		{
+			name:   "mysql",
+			source: "prql dialect:mysql" + pipeline,
+			expected: `
+SELECT t.*, CHAR_LENGTH(LOWER(name)) AS l, REPLACE(TRIM(name), 'a', 'b') AS r
+FROM t
+WHERE LOCATE(CAST('50%_off' AS BINARY), CAST(name AS BINARY)) > 0 AND LOCATE(CAST(prefix AS BINARY), CAST(name AS BINARY)) = 1 OR LOCATE(CAST(code AS BINARY), CAST(name AS BINARY)) > 0`,
+		},
+		{
+			name:   "sqlite",
+			source: "prql dialect:sqlite" + pipeline,
+			expected: `
+SELECT t.*, LENGTH(LOWER(name)) AS l, REPLACE(TRIM(name), 'a', 'b') AS r
+FROM t
+WHERE INSTR(name, '50%_off') > 0 AND SUBSTR(name, 1, LENGTH(prefix)) = prefix OR INSTR(name, code) > 0`,
+		},
+		{
+			name: "brackets escaped for mssql",
+			source: `prql dialect:mssql
+from t
+filter (text.starts_with "[A]" name ignore_case:true)`,
+			expected: `
+SELECT *
+FROM t
+WHERE LOWER(name) LIKE '\[a]%' ESCAPE '\'`,
+		},
+		{
+			name: "comparison within an expression",
+			source: `
+from t
+derive [x = (text.contains code name) == false]`,
+			expected: `
+SELECT t.*, (POSITION(code IN name) > 0) = FALSE AS x
+FROM t`,
+		},
+	})
+}
+
+func TestCompileTextMatchCase(t *testing.T) {
+	const pipeline = `
+from t
+filter (status | text.starts_with "PA") or (status | text.contains "id" ignore_case:false)
+filter (status | text.contains "Pa" ignore_case:true)`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "generic",
+			source: pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE (status LIKE 'PA%' OR status LIKE '%id%') AND LOWER(status) LIKE '%pa%'`,
+		},
+		{
+			name:   "sqlite",
+			source: "prql dialect:sqlite" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE (SUBSTR(status, 1, LENGTH('PA')) = 'PA' OR INSTR(status, 'id') > 0) AND LOWER(status) LIKE '%pa%'`,
+		},
+		{
+			name:   "mysql",
+			source: "prql dialect:mysql" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE (LOCATE(CAST('PA' AS BINARY), CAST(status AS BINARY)) = 1 OR LOCATE(CAST('id' AS BINARY), CAST(status AS BINARY)) > 0) AND LOWER(status) LIKE '%pa%'`,
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE (SUBSTRING(status, 1, LEN('PA')) = 'PA' COLLATE Latin1_General_BIN2 OR CHARINDEX('id', status COLLATE Latin1_General_BIN2) > 0) AND LOWER(status) LIKE '%pa%'`,
+		},
+	})
+}
+
+func TestCompileMathFunctions(t *testing.T) {
+	const pipeline = `
+from t
+derive [r = math.round 2 total, c = math.ceil x, p = math.pow 2 x, h = a / b, f = a / 2.0]`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "generic",
+			source: pipeline,
+			expected: `
+SELECT t.*, ROUND(total, 2) AS r, CEIL(x) AS c, POWER(x, 2) AS p, a / b AS h, a / 2.0 AS f
+FROM t`,
+		},
+		{
+			name:   "postgres",
+			source: "prql dialect:postgres" + pipeline,
+			expected: `
+SELECT t.*, ROUND(total::NUMERIC, 2) AS r, CEIL(x) AS c, POWER(x, 2) AS p, a::DOUBLE PRECISION / b AS h, a / 2.0 AS f
+FROM t`,
+		},
+		{
+			name:     "postgres casts an operation",
+			source:   "prql dialect:postgres\nfrom t\nderive r = math.round 2 (a + b)",
+			expected: "SELECT t.*, ROUND((a + b)::NUMERIC, 2) AS r\nFROM t",
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql" + pipeline,
+			expected: `
+SELECT t.*, ROUND(total, 2) AS r, CEILING(x) AS c, POWER(CAST(x AS FLOAT), 2) AS p, CAST(a AS FLOAT) / b AS h, a / 2.0 AS f
+FROM t`,
+		},
+		{
+			name:   "mysql",
+			source: "prql dialect:mysql" + pipeline,
+			expected: `
+SELECT t.*, SIGN(total) * FLOOR(ABS(total) * POWER(10, 2) + 0.5) / POWER(10, 2) AS r, CEIL(x) AS c, POWER(x, 2) AS p, a / b AS h, a / 2.0 AS f
+FROM t`,
+		},
+		{
+			name:   "sqlite",
+			source: "prql dialect:sqlite" + pipeline,
+			expected: `
+SELECT t.*, ROUND(total, 2) AS r, CEIL(x) AS c, POWER(x, 2) AS p, CAST(a AS REAL) / b AS h, a / 2.0 AS f
+FROM t`,
+		},
+		{
+			name: "template operators within expressions",
+			source: `prql dialect:mysql
+from t
+derive [r = 2 / (math.round 0 (a + b))]`,
+			expected: `
+SELECT t.*, 2 / (SIGN(a + b) * FLOOR(ABS(a + b) * POWER(10, 0) + 0.5) / POWER(10, 0)) AS r
+FROM t`,
+		},
+	})
+}
+
+func TestCompileFunctionArguments(t *testing.T) {
+	compileError(t, "from t\naggregate [average \"x\"]", "2:12: function 'average' expects argument 1 to be numeric but found string")
+	compileError(t, "from t\naggregate [any 1]", "function 'any' expects argument 1 to be boolean but found integer")
+	compileError(t, "from t\naggregate [count_distinct a b]", "function 'count_distinct' expects 1 arguments but received 2")
+	compileError(t, "from t\nderive [l = text.length 5]", "function 'text.length' expects argument 1 to be text but found integer")
+	compileError(t, "from t\naggregate [median x]", "unknown function 'median'")
+
+	query, err := parser.Parse("prql dialect:sqlite\nfrom t\naggregate [stddev x]")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+	_, err = compiler.Compile(query, compiler.Options{})
+
+	var unsupported compiler.UnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Feature != "function 'stddev'" {
+		t.Errorf("expected UnsupportedError for 'stddev', received %v", err)
+	}
+}
@@ -0,0 +1,714 @@
+package compiler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// indent is prepended to each line of a nested query
+const indent = "  "
+
+// reBareIdent matches identifiers which do not need to be quoted. Any upper
+// case characters are quoted so their case is kept.
+var reBareIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reFoldedIdent matches the names which may be left bare for the database to
+// fold their case
+var reFoldedIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedWords holds SQL keywords which must be quoted when used as names
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "asc": true, "between": true,
+	"by": true, "case": true, "cross": true, "default": true, "desc": true,
+	"distinct": true, "else": true, "end": true, "except": true, "false": true,
+	"fetch": true, "from": true, "full": true, "group": true, "having": true,
+	"in": true, "inner": true, "intersect": true, "is": true, "join": true,
+	"left": true, "like": true, "limit": true, "not": true, "null": true,
+	"offset": true, "on": true, "or": true, "order": true, "outer": true,
+	"over": true, "right": true, "rows": true, "select": true, "table": true,
+	"then": true, "top": true, "true": true, "union": true, "user": true,
+	"using": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// dialectReservedWords holds the further keywords reserved by some dialects
+var dialectReservedWords = map[syntax.Dialect]map[string]bool{
+	syntax.DialectMSSQL: {
+		"browse": true, "close": true, "file": true, "identity": true, "key": true,
+		"open": true, "percent": true, "plan": true, "proc": true, "read": true,
+		"rule": true, "tran": true,
+	},
+	syntax.DialectSnowflake: {
+		"column": true, "connection": true, "constraint": true, "ilike": true,
+		"increment": true, "lateral": true, "minus": true, "qualify": true,
+		"regexp": true, "rlike": true, "sample": true, "start": true,
+		"unique": true, "values": true, "with": true,
+	},
+}
+
+// Operator precedence used to decide where parenthesis are required
+const (
+	precOr = iota + 1
+	precAnd
+	precNot
+	precCompare
+	precConcat
+	precAdd
+	precMultiply
+	precUnary
+	precAtom
+)
+
+// binaryPrecedence holds the precedence of each SQL binary operator
+var binaryPrecedence = map[string]int{
+	"OR":     precOr,
+	"AND":    precAnd,
+	"=":      precCompare,
+	"<>":     precCompare,
+	"<":      precCompare,
+	"<=":     precCompare,
+	">":      precCompare,
+	">=":     precCompare,
+	"IS":     precCompare,
+	"IS NOT": precCompare,
+	"<=>":    precCompare,
+	"~":      precCompare,
+	"REGEXP": precCompare,
+	"RLIKE":  precCompare,
+	"||":     precConcat,
+	"+":      precAdd,
+	"-":      precAdd,
+	"*":      precMultiply,
+	"/":      precMultiply,
+	"%":      precMultiply,
+
+	"IS NOT DISTINCT FROM": precCompare,
+	"LIKE_REGEX":           precCompare,
+}
+
+// associative holds the operators which do not need parenthesis when the
+// right operand uses the same operator.
+var associative = map[string]bool{
+	"OR":  true,
+	"AND": true,
+	"||":  true,
+	"+":   true,
+	"*":   true,
+}
+
+// generator renders the SQL model into text for a dialect.
+type generator struct {
+	target Dialect
+}
+
+// generate renders the final query into SQL text, after the WITH clause of
+// any common tables.
+func (c *compiler) generate(query *selectQuery) string {
+	g := &generator{target: c.target}
+	lines := g.withLines(c.tables)
+	lines = append(lines, g.selectLines(query)...)
+	return strings.Join(lines, "\n")
+}
+
+// withLines renders the WITH clause declaring the common tables. Dialects
+// without CapabilityRecursiveKeyword, such as MSSQL, do not use the
+// "RECURSIVE" keyword, as any of their common tables may be.
+func (g *generator) withLines(tables []*commonTable) []string {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	keyword := "WITH "
+	for _, table := range tables {
+		if table.recursive && g.target.Supports(CapabilityRecursiveKeyword) {
+			keyword = "WITH RECURSIVE "
+		}
+	}
+
+	var lines []string
+	for i, table := range tables {
+		if i > 0 {
+			lines[len(lines)-1] += ","
+			keyword = ""
+		}
+		lines = append(lines, keyword+g.ident(table.name)+" AS (")
+		for _, line := range g.selectLines(table.query) {
+			lines = append(lines, indent+line)
+		}
+		lines = append(lines, ")")
+	}
+	return lines
+}
+
+// selectLines renders a SELECT query, with each clause on it's own line. Any
+// queries combined by set operations follow it, with ordered queries within
+// parenthesis.
+func (g *generator) selectLines(q *selectQuery) []string {
+	if len(q.compound) == 0 {
+		return g.clauseLines(q)
+	}
+
+	first := *q
+	first.compound = nil
+	lines := g.operandLines(&first)
+	for _, op := range q.compound {
+		lines = append(lines, g.setOperator(op.op))
+		lines = append(lines, g.operandLines(op.query)...)
+	}
+	return lines
+}
+
+// operandLines renders a query used by a set operation.
+func (g *generator) operandLines(q *selectQuery) []string {
+	lines := g.selectLines(q)
+	if !q.ordered() {
+		return lines
+	}
+
+	wrapped := []string{"("}
+	for _, line := range lines {
+		wrapped = append(wrapped, indent+line)
+	}
+	return append(wrapped, ")")
+}
+
+// setOperator renders the SQL operator of a set operation. Dialects without
+// CapabilityImplicitDistinct, such as BigQuery, require operators without
+// "ALL" to be explicitly "DISTINCT".
+func (g *generator) setOperator(op string) string {
+	if !g.target.Supports(CapabilityImplicitDistinct) && !strings.HasSuffix(op, " ALL") {
+		return op + " DISTINCT"
+	}
+	return op
+}
+
+// clauseLines renders the clauses of a single SELECT query. Expressions which
+// span many lines, such as an EXISTS subquery, are split into separate lines.
+func (g *generator) clauseLines(q *selectQuery) []string {
+	var sel strings.Builder
+	sel.WriteString("SELECT ")
+	if q.distinct {
+		sel.WriteString("DISTINCT ")
+	}
+	top, limitClauses := g.target.Limit(q.limit, q.offset, len(q.orderBy) > 0)
+	if top != "" {
+		sel.WriteString(top + " ")
+	}
+	if q.distinctOn != nil {
+		sel.WriteString("DISTINCT ON (" + g.exprList(q.distinctOn) + ") ")
+	}
+	for i, col := range q.columns {
+		if i > 0 {
+			sel.WriteString(", ")
+		}
+		sel.WriteString(g.expr(col.expr))
+		if col.alias != "" {
+			sel.WriteString(" AS " + g.ident(col.alias))
+		}
+	}
+
+	// Dialects without "RIGHT JOIN" swap the relations of a LEFT JOIN instead,
+	// which the compiler places first. The columns remain in the same order, as
+	// they are selected by name.
+	from, joins := q.from, q.joins
+	if len(joins) > 0 && joins[0].side == "RIGHT JOIN" && !g.target.Supports(CapabilityRightJoin) {
+		swapped := *joins[0]
+		from, swapped.table, swapped.side = swapped.table, from, "LEFT JOIN"
+		joins = append([]*joinClause{&swapped}, joins[1:]...)
+	}
+
+	lines := []string{sel.String()}
+	lines = append(lines, g.tableLines("FROM ", from)...)
+	for _, join := range joins {
+		lines = append(lines, g.joinLines(join)...)
+	}
+
+	if q.where != nil {
+		lines = append(lines, "WHERE "+g.condition(q.where))
+	} else if q.qualify != nil && len(q.groupBy) == 0 && q.having == nil && !g.target.Supports(CapabilityBareQualify) {
+		// BigQuery only allows "QUALIFY" within a query which also filters or
+		// groups it's rows
+		lines = append(lines, "WHERE TRUE")
+	}
+	if len(q.groupBy) > 0 {
+		lines = append(lines, "GROUP BY "+g.exprList(q.groupBy))
+	}
+	if q.having != nil {
+		lines = append(lines, "HAVING "+g.condition(q.having))
+	}
+	if q.qualify != nil {
+		lines = append(lines, "QUALIFY "+g.condition(q.qualify))
+	}
+	if len(q.orderBy) > 0 {
+		lines = append(lines, "ORDER BY "+g.sortList(q.orderBy))
+	}
+	if q.limitBy != nil {
+		lines = append(lines, g.limitBy(q.limitBy))
+	}
+	lines = append(lines, limitClauses...)
+
+	split := make([]string, 0, len(lines))
+	for _, line := range lines {
+		split = append(split, strings.Split(line, "\n")...)
+	}
+	return split
+}
+
+// tableLines renders a relation of a FROM or JOIN clause. Subqueries are
+// indented on their own lines.
+func (g *generator) tableLines(keyword string, ref *tableRef) []string {
+	if ref.series != nil {
+		return []string{keyword + g.generateSeries(ref.series) + " AS " + g.ident(ref.alias) + "(" + g.ident(ref.series.column) + ")"}
+	}
+	if ref.subquery == nil {
+		line := keyword + g.identPath(ref.name)
+		if ref.alias != "" {
+			line += " AS " + g.ident(ref.alias)
+		}
+		return []string{line}
+	}
+
+	lines := []string{keyword + "("}
+	for _, line := range g.selectLines(ref.subquery) {
+		lines = append(lines, indent+line)
+	}
+	return append(lines, ") AS "+g.ident(ref.alias))
+}
+
+// joinLines renders a JOIN clause, with the condition following the relation.
+func (g *generator) joinLines(join *joinClause) []string {
+	lines := g.tableLines(join.side+" ", join.table)
+	last := len(lines) - 1
+
+	if len(join.using) > 0 {
+		names := make([]string, len(join.using))
+		for i, name := range join.using {
+			names[i] = g.ident(name)
+		}
+		lines[last] += " USING (" + strings.Join(names, ", ") + ")"
+	} else if join.on != nil {
+		lines[last] += " ON " + g.condition(join.on)
+	} else {
+		lines[last] += " ON " + g.condition(literalExpr{syntax.TypeBoolean, "true"})
+	}
+	return lines
+}
+
+// ident renders a single identifier, quoting it if required.
+func (g *generator) ident(name string) string {
+	return g.target.QuoteIdent(name)
+}
+
+// identPath renders a dot separated identifier, quoting each part. Dialects
+// without CapabilityPathQualifiers, such as BigQuery, quote the whole path
+// instead, as in "`my-project.dataset.table`".
+func (g *generator) identPath(path string) string {
+	parts := strings.Split(path, ".")
+	quoted := false
+	for i, part := range parts {
+		parts[i] = g.ident(part)
+		quoted = quoted || parts[i] != part
+	}
+	if quoted && len(parts) > 1 && !g.target.Supports(CapabilityPathQualifiers) {
+		return g.target.QuoteIdent(path)
+	}
+	return strings.Join(parts, ".")
+}
+
+// qualifier renders the name of a relation qualifying it's columns. Dialects
+// without CapabilityPathQualifiers, such as BigQuery, name a table by the last
+// part of it's path, such as "orders" for "dataset.orders".
+func (g *generator) qualifier(relation string) string {
+	if !g.target.Supports(CapabilityPathQualifiers) {
+		relation = relation[strings.LastIndexByte(relation, '.')+1:]
+	}
+	return g.identPath(relation)
+}
+
+func (g *generator) exprList(exprs []sqlExpr) string {
+	items := make([]string, len(exprs))
+	for i, expr := range exprs {
+		items[i] = g.expr(expr)
+	}
+	return strings.Join(items, ", ")
+}
+
+// template renders the SQL of a function template, replacing "{0}", "{1}" and
+// so on with the arguments. Returns the precedence of the template's outer-most
+// operator, or the template is treated as a single call.
+func (g *generator) template(template string, args []sqlExpr) (string, int) {
+	prec := templatePrecedence(template)
+
+	var str strings.Builder
+	depth := 0
+	quoted := false
+	for i := 0; i < len(template); i++ {
+		switch char := template[i]; {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if n, err := strconv.Atoi(template[i+1 : i+end]); err == nil && n < len(args) {
+				// Arguments cast with "::" or given a collation are operands of
+				// the cast or COLLATE, which bind tighter than any other
+				// operator. Arguments outside of any parenthesis are operands
+				// of the outer-most operator.
+				if rest := template[i+end+1:]; strings.HasPrefix(rest, "::") || strings.HasPrefix(rest, " COLLATE ") {
+					str.WriteString(g.wrap(args[n], precAtom))
+				} else if depth == 0 {
+					str.WriteString(g.wrap(args[n], prec+1))
+				} else {
+					str.WriteString(g.expr(args[n]))
+				}
+				i += end
+				continue
+			}
+		}
+		str.WriteByte(template[i])
+	}
+	return str.String(), prec
+}
+
+// concatParts renders the strings of a chain of concatenations, with any
+// operations of a lower precedence within parenthesis.
+func (g *generator) concatParts(expr sqlExpr) []string {
+	if bin, ok := expr.(binaryExpr); ok && bin.op == "||" {
+		return append(g.concatParts(bin.left), g.concatParts(bin.right)...)
+	}
+	return []string{g.wrap(expr, precConcat+1)}
+}
+
+// templateOperators holds the precedence of operators which may be used
+// outside of parenthesis within function templates
+var templateOperators = map[byte]int{
+	'=': precCompare,
+	'<': precCompare,
+	'>': precCompare,
+	'+': precAdd,
+	'-': precAdd,
+	'*': precMultiply,
+	'/': precMultiply,
+	'%': precMultiply,
+	'|': precConcat,
+	'~': precCompare,
+}
+
+// templatePrecedence returns the lowest precedence of the operators within the
+// template which are outside of any parenthesis or quotes. Operators named by
+// words, such as "RLIKE", are those of binaryPrecedence between spaces.
+func templatePrecedence(template string) int {
+	prec := precAtom
+	depth := 0
+	quoted := false
+	for i := 0; i < len(template); i++ {
+		switch char := template[i]; {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case depth == 0 && char == ' ':
+			word := template[i+1:]
+			if end := strings.IndexByte(word, ' '); end > 0 {
+				if op, ok := binaryPrecedence[word[:end]]; ok && op < prec {
+					prec = op
+				}
+			}
+		case depth == 0:
+			if op, ok := templateOperators[char]; ok && op < prec {
+				prec = op
+			}
+		}
+	}
+	return prec
+}
+
+// backslashEscapes returns true if the dialect uses a backslash to escape the
+// characters of a string literal, so a backslash itself must be escaped.
+func backslashEscapes(dialect syntax.Dialect) bool {
+	switch dialect {
+	case syntax.DialectBigQuery, syntax.DialectClickHouse, syntax.DialectHive, syntax.DialectMYSQL, syntax.DialectSnowflake:
+		return true
+	}
+	return false
+}
+
+// sortList renders the items of an ORDER BY. When the dialect does not support
+// "NULLS FIRST" or "NULLS LAST", the nulls are ordered by a CASE expression
+// placed before the item.
+func (g *generator) sortList(items []sortItem) string {
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		str := g.expr(item.expr)
+		if item.desc {
+			str += " DESC"
+		}
+
+		switch {
+		case item.nulls == NullsDefault:
+		case g.target.Supports(CapabilityNullsOrder):
+			if item.nulls == NullsFirst {
+				str += " NULLS FIRST"
+			} else {
+				str += " NULLS LAST"
+			}
+		default:
+			first, rest := "0", "1"
+			if item.nulls == NullsLast {
+				first, rest = rest, first
+			}
+			strs = append(strs, "CASE WHEN "+g.wrap(item.expr, precCompare+1)+" IS NULL THEN "+first+" ELSE "+rest+" END")
+		}
+
+		strs = append(strs, str)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// expr renders an expression.
+func (g *generator) expr(expr sqlExpr) string {
+	str, _ := g.exprPrec(expr)
+	return str
+}
+
+// wrap renders an expression, adding parenthesis when it's precedence is
+// lower than the minimum given.
+func (g *generator) wrap(expr sqlExpr, min int) string {
+	str, prec := g.exprPrec(expr)
+	if prec < min {
+		return "(" + str + ")"
+	}
+	return str
+}
+
+// exprPrec renders an expression as a value, returning the precedence of it's
+// outer-most operator. Dialects without boolean values have conditions
+// converted into 1 or 0.
+func (g *generator) exprPrec(expr sqlExpr) (string, int) {
+	str, prec := g.render(expr)
+	if isCondition(expr, prec) && !g.target.Supports(CapabilityBooleans) {
+		return "CASE WHEN " + str + " THEN 1 ELSE 0 END", precAtom
+	}
+	return str, prec
+}
+
+// condition renders an expression used as a condition, such as of a WHERE
+// clause.
+func (g *generator) condition(expr sqlExpr) string {
+	str, _ := g.conditionPrec(expr)
+	return str
+}
+
+// wrapCondition renders an expression used as a condition, adding parenthesis
+// when it's precedence is lower than the minimum given.
+func (g *generator) wrapCondition(expr sqlExpr, min int) string {
+	str, prec := g.conditionPrec(expr)
+	if prec < min {
+		return "(" + str + ")"
+	}
+	return str
+}
+
+// conditionPrec renders an expression used as a condition, returning the
+// precedence of it's outer-most operator. Dialects without boolean values
+// compare any other value with 1, such as a column of bits.
+func (g *generator) conditionPrec(expr sqlExpr) (string, int) {
+	str, prec := g.render(expr)
+	if _, raw := expr.(rawExpr); raw || isCondition(expr, prec) || g.target.Supports(CapabilityBooleans) {
+		return str, prec
+	}
+	if prec <= precCompare {
+		str = "(" + str + ")"
+	}
+	return str + " = 1", precCompare
+}
+
+// isCondition returns true if the rendered expression is a condition rather
+// than a value, such as a comparison.
+func isCondition(expr sqlExpr, prec int) bool {
+	switch expr.(type) {
+	case existsExpr:
+		return true
+	case rawExpr, literalExpr:
+		return false
+	}
+	return prec <= precCompare
+}
+
+// render renders an expression, returning the precedence of it's outer-most
+// operator.
+func (g *generator) render(expr sqlExpr) (string, int) {
+	switch e := expr.(type) {
+	case columnRef:
+		if e.relation != "" {
+			return g.qualifier(e.relation) + "." + g.ident(e.name), precAtom
+		}
+		return g.ident(e.name), precAtom
+
+	case starExpr:
+		str := "*"
+		if e.relation != "" {
+			str = g.qualifier(e.relation) + ".*"
+		}
+		if len(e.except) > 0 {
+			names := make([]string, len(e.except))
+			for i, name := range e.except {
+				names[i] = g.ident(name)
+			}
+			keyword := " EXCEPT ("
+			if g.target.Supports(CapabilityStarExclude) {
+				keyword = " EXCLUDE ("
+			}
+			str += keyword + strings.Join(names, ", ") + ")"
+		}
+		return str, precAtom
+
+	case literalExpr:
+		return g.literal(e), precAtom
+
+	case intervalExpr:
+		return g.target.Interval(e.value, strings.TrimSuffix(e.unit, "s")), precAtom
+
+	case unaryExpr:
+		if e.op == "NOT" {
+			return "NOT " + g.wrapCondition(e.operand, precNot), precNot
+		}
+		return e.op + g.wrap(e.operand, precUnary), precUnary
+
+	case binaryExpr:
+		if e.op == "||" {
+			str := g.target.Concat(g.concatParts(e))
+			return str, templatePrecedence(str)
+		}
+
+		prec := binaryPrecedence[e.op]
+		left, right := prec, prec+1
+		if operand, ok := e.right.(binaryExpr); ok && operand.op == e.op && associative[e.op] {
+			right = prec
+		}
+		if prec <= precAnd {
+			return g.wrapCondition(e.left, left) + " " + e.op + " " + g.wrapCondition(e.right, right), prec
+		}
+		// Comparisons do not chain, so a comparison of comparisons needs
+		// parenthesis on both sides
+		if prec == precCompare {
+			left = prec + 1
+		}
+		return g.wrap(e.left, left) + " " + e.op + " " + g.wrap(e.right, right), prec
+
+	case funcExpr:
+		if e.filter != nil {
+			filter := e.filter
+			e.filter = nil
+			return g.expr(e) + " FILTER (WHERE " + g.expr(filter) + ")", precAtom
+		}
+		if e.star {
+			return e.name + "(*)", precAtom
+		}
+		if e.template != "" {
+			return g.template(e.template, e.args)
+		}
+		return e.name + "(" + g.exprList(e.args) + ")", precAtom
+
+	case windowExpr:
+		var over []string
+		if len(e.partition) > 0 {
+			over = append(over, "PARTITION BY "+g.exprList(e.partition))
+		}
+		if len(e.order) > 0 {
+			over = append(over, "ORDER BY "+g.sortList(e.order))
+		}
+		if e.frame != nil {
+			over = append(over, e.frame.kind+" BETWEEN "+g.frameBound(e.frame.start)+" AND "+g.frameBound(e.frame.end))
+		}
+		return g.expr(e.fn) + " OVER (" + strings.Join(over, " ") + ")", precAtom
+
+	case castExpr:
+		return g.template(g.target.Cast(e.typ), []sqlExpr{e.value})
+
+	case likeExpr:
+		op := " LIKE "
+		if e.insensitive {
+			op = " ILIKE "
+		}
+		str := g.wrap(e.value, precCompare+1) + op + g.literal(literalExpr{syntax.TypeString, e.pattern})
+		if strings.ContainsRune(e.pattern, '\\') && !g.target.Supports(CapabilityLikeBackslash) {
+			str += " ESCAPE " + g.literal(literalExpr{syntax.TypeString, `\`})
+		}
+		return str, precCompare
+
+	case caseExpr:
+		if template, ok := g.target.Function("case", ""); ok && len(e.whens) == 1 && e.els != nil {
+			return g.template(template, []sqlExpr{e.whens[0].cond, e.whens[0].value, e.els})
+		}
+		var str strings.Builder
+		str.WriteString("CASE")
+		for _, when := range e.whens {
+			str.WriteString(" WHEN " + g.condition(when.cond) + " THEN " + g.expr(when.value))
+		}
+		if e.els != nil {
+			str.WriteString(" ELSE " + g.expr(e.els))
+		}
+		str.WriteString(" END")
+		return str.String(), precAtom
+
+	case existsExpr:
+		str := "EXISTS (\n"
+		for _, line := range g.selectLines(e.query) {
+			str += indent + line + "\n"
+		}
+		str += ")"
+		if e.not {
+			return "NOT " + str, precNot
+		}
+		return str, precAtom
+
+	case rawExpr:
+		var str strings.Builder
+		for _, part := range e.parts {
+			if part.expr != nil {
+				str.WriteString(g.expr(part.expr))
+			} else {
+				str.WriteString(part.text)
+			}
+		}
+		return str.String(), precAtom
+	}
+
+	return "", precAtom
+}
+
+// frameBound renders one end of a window frame.
+func (g *generator) frameBound(bound frameBound) string {
+	switch {
+	case bound.unbounded && bound.preceding:
+		return "UNBOUNDED PRECEDING"
+	case bound.unbounded:
+		return "UNBOUNDED FOLLOWING"
+	case bound.offset == nil:
+		return "CURRENT ROW"
+	case bound.preceding:
+		return g.expr(bound.offset) + " PRECEDING"
+	}
+	return g.expr(bound.offset) + " FOLLOWING"
+}
+
+// literal renders a constant value.
+func (g *generator) literal(lit literalExpr) string {
+	switch {
+	case lit.isNull():
+		return "NULL"
+	case lit.typ == syntax.TypeBoolean:
+		return g.target.Boolean(lit.value == "true")
+	}
+	return g.target.Literal(lit.typ, lit.value)
+}
@@ -0,0 +1,75 @@
+package compiler_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the expected SQL of the golden tests with the SQL compiled
+var update = flag.Bool("update", false, "update the SQL files of the golden tests")
+
+// goldenDialects lists the directories within testdata holding golden tests,
+// one for each dialect.
+var goldenDialects = []string{
+	"bigquery",
+	"clickhouse",
+	"hive",
+	"mssql",
+	"mysql",
+	"postgres",
+	"snowflake",
+	"sqlite",
+}
+
+func TestGolden(t *testing.T) {
+	for _, dir := range goldenDialects {
+		dir := dir
+		t.Run(dir, func(t *testing.T) {
+			runGoldenTests(t, dir)
+		})
+	}
+}
+
+// runGoldenTests compiles each PRQL file of the directory within testdata,
+// comparing the SQL with the file of the same name and the ".sql" extension.
+func runGoldenTests(t *testing.T, dir string) {
+	t.Helper()
+
+	sources, err := filepath.Glob(filepath.Join("testdata", dir, "*.prql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) == 0 {
+		t.Fatalf("no golden tests found within testdata/%s", dir)
+	}
+
+	for _, source := range sources {
+		source := source
+		name := strings.TrimSuffix(filepath.Base(source), ".prql")
+		t.Run(name, func(t *testing.T) {
+			prql, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql := compile(t, string(prql))
+
+			golden := strings.TrimSuffix(source, ".prql") + ".sql"
+			if *update {
+				if err := os.WriteFile(golden, []byte(sql+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != strings.TrimSpace(string(expected)) {
+				t.Errorf("unexpected SQL\nexpected:\n%s\nreceived:\n%s", strings.TrimSpace(string(expected)), sql)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// pipelineStages returns the stages of a nested pipeline. A single transform
+// without any pipes is a pipeline of one stage.
+func pipelineStages(expr syntax.Expr) []syntax.Expr {
+	if pipeline, ok := expr.(syntax.Pipeline); ok {
+		return pipeline.Stages
+	}
+	return []syntax.Expr{expr}
+}
+
+// group applies a nested pipeline to each group of rows sharing the same keys.
+//
+// An "aggregate" within the group becomes a GROUP BY. Other transforms are
+// applied to each partition using window functions, such as "take" which
+// numbers the rows of each partition with ROW_NUMBER() and filters them within
+// an outer query. Taking a single row of groups by every column selects the
+// distinct rows instead.
+func (r *relation) group(call syntax.Call) error {
+	if len(call.Args) != 2 {
+		return errorf(call.Pos(), "'group' expects the grouping columns and a pipeline")
+	}
+
+	keys := listItems(call.Args[0])
+	stages := pipelineStages(call.Args[1])
+
+	// The sort within the group only applies to the group. Without one, the
+	// current order of the relation is used.
+	var order *syntax.Call
+	for i, stage := range stages {
+		transform, ok := stage.(syntax.Call)
+		if !ok {
+			return errorf(stage.Pos(), "expected a transform but found %s", stage.String())
+		}
+
+		var err error
+		switch transform.Name {
+		case "aggregate":
+			if i != len(stages)-1 {
+				return errorf(stages[i+1].Pos(), "'aggregate' must be the last transform within 'group'")
+			}
+			err = r.aggregate(transform, keys)
+		case "sort":
+			sortCall := transform
+			order = &sortCall
+		case "take":
+			err = r.takeGrouped(transform, keys, order)
+		case "derive":
+			err = r.derive(transform, &windowSpec{partition: keys, order: order})
+		case "window":
+			err = r.window(transform, keys, order)
+		default:
+			err = errorf(transform.Pos(), "'%s' is not supported within 'group'", transform.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeGrouped takes a range of rows from each group, in the order given.
+func (r *relation) takeGrouped(call syntax.Call, keys []syntax.Expr, order *syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+	start, end, err := takeRange(arg)
+	if err != nil {
+		return err
+	}
+
+	qualify := r.c.target.Supports(CapabilityQualify) && !r.emulatesFullJoin()
+	if r.stage >= stageDistinct || (qualify && r.stage >= stageQualify) {
+		r.split()
+	}
+
+	partition, err := r.translateExprs(keys)
+	if err != nil {
+		return err
+	}
+	sort := r.sort
+	if order != nil {
+		if sort, err = r.translateSort(order); err != nil {
+			return err
+		}
+	}
+
+	// Taking one row of groups by every column is the same as the distinct rows
+	if start == 1 && end == 1 && r.isDistinct(partition) {
+		r.distinct()
+		return nil
+	}
+
+	// Postgres keeps the first row of each group with DISTINCT ON
+	if r.c.target.Supports(CapabilityDistinctOn) && start == 1 && end == 1 {
+		orderBy := make([]sortItem, 0, len(partition)+len(sort))
+		for _, part := range partition {
+			orderBy = append(orderBy, sortItem{expr: part, nulls: r.c.opts.Nulls})
+		}
+		r.query.distinctOn = partition
+		r.query.orderBy = append(orderBy, sort...)
+		r.stage = stageDistinct
+		return nil
+	}
+
+	// ClickHouse keeps the first rows of each group with LIMIT BY
+	if r.c.target.Supports(CapabilityLimitBy) && end >= 0 {
+		r.query.limitBy = &limitBy{limit: end - start + 1, offset: start - 1, by: partition}
+		r.query.orderBy = sort
+		r.stage = stageDistinct
+		return nil
+	}
+
+	rowNumber, err := r.c.callFunction(call.Pos(), "row_number", nil, nil)
+	if err != nil {
+		return err
+	}
+	var ref sqlExpr = windowExpr{fn: rowNumber, partition: partition, order: sort}
+
+	// Without "QUALIFY", the row numbers are a hidden column of a subquery,
+	// which is only left out of all columns with "* EXCEPT"
+	if !qualify {
+		if r.hasStar() && !r.c.target.Supports(CapabilityStarExcept) {
+			return r.c.unsupported(call.Pos(), "'take' of a relation with unknown columns")
+		}
+		name := r.c.nextExprName()
+		r.columns = append(r.columns, &column{name: name, expr: ref, hidden: true})
+		r.split()
+		ref = columnRef{name: name}
+	}
+
+	var cond sqlExpr
+	if start > 1 {
+		cond = binaryExpr{">=", ref, literalInt(start)}
+	}
+	if end >= 0 {
+		cond = and(cond, binaryExpr{"<=", ref, literalInt(end)})
+	}
+
+	if qualify {
+		r.query.qualify = and(r.query.qualify, cond)
+		r.stage = stageQualify
+	} else {
+		r.query.where = cond
+		r.stage = stageWhere
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileGroup(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "aggregate",
+			source: `
+from employees
+group [title, country] (aggregate [average salary, ct = count])`,
+			expected: `
+SELECT title, country, AVG(salary), COUNT(*) AS ct
+FROM employees
+GROUP BY title, country`,
+		},
+		{
+			name: "top per group with known columns",
+			source: `
+from invoices
+select [customer, date, total]
+group customer (sort [-total, date] | take 3)`,
+			expected: `
+SELECT customer, date, total
+FROM (
+  SELECT customer, date, total, ROW_NUMBER() OVER (PARTITION BY customer ORDER BY total DESC, date) AS _expr_0
+  FROM invoices
+) AS table_0
+WHERE _expr_0 <= 3`,
+		},
+		{
+			name: "postgres distinct on",
+			source: `
+prql dialect:postgres
+from invoices
+group customer (sort -date | take 1)`,
+			expected: `
+SELECT DISTINCT ON (customer) *
+FROM invoices
+ORDER BY customer, date DESC`,
+		},
+		{
+			name: "postgres keeps window when taking many",
+			source: `
+prql dialect:postgres
+from invoices
+select [customer, date, total]
+group customer (sort -date | take 2)`,
+			expected: `
+SELECT customer, date, total
+FROM (
+  SELECT customer, date, total, ROW_NUMBER() OVER (PARTITION BY customer ORDER BY date DESC) AS _expr_0
+  FROM invoices
+) AS table_0
+WHERE _expr_0 <= 2`,
+		},
+		{
+			name: "windowed derive",
+			source: `
+from employees
+group dept (sort salary | derive [r = rank, total = sum salary])`,
+			expected: `
+SELECT employees.*, RANK() OVER (PARTITION BY dept ORDER BY salary) AS r, SUM(salary) OVER (PARTITION BY dept) AS total
+FROM employees`,
+		},
+		{
+			name: "take after limit",
+			source: `
+from employees
+select [dept, name, salary]
+take 100
+group dept (sort salary | take 1)`,
+			expected: `
+SELECT dept, name, salary
+FROM (
+  SELECT dept, name, salary, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary) AS _expr_0
+  FROM (
+    SELECT dept, name, salary
+    FROM employees
+    LIMIT 100
+  ) AS table_0
+) AS table_1
+WHERE _expr_0 <= 1`,
+		},
+	})
+}
+
+func TestCompileGroupErrors(t *testing.T) {
+	compileError(t, "from e\ngroup a (filter b > 1)", "'filter' is not supported within 'group'")
+	compileError(t, "from e\ngroup a (aggregate [count] | take 1)", "'aggregate' must be the last transform within 'group'")
+	compileError(t, "from invoices\ngroup customer (sort -date | take 1)", "2:30: 'take' of a relation with unknown columns in 'group' is not supported by dialect generic")
+	compileError(t, "prql dialect:sqlite\nfrom t\ngroup [a, b] (take 1)", "3:15: 'take' of a relation with unknown columns in 'group' is not supported by dialect sqlite")
+}
@@ -0,0 +1,293 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// joinSides maps the accepted "side" parameters of a join to their SQL
+var joinSides = map[string]string{
+	"inner": "JOIN",
+	"left":  "LEFT JOIN",
+	"right": "RIGHT JOIN",
+	"full":  "FULL JOIN",
+}
+
+// joinClause is a JOIN of another relation within a SELECT. Either the columns
+// of USING are given, or the ON condition.
+type joinClause struct {
+	side  string
+	table *tableRef
+	using []string
+	on    sqlExpr
+}
+
+// relationName returns the name used to qualify the columns of a relation
+// within the FROM or JOIN clause.
+func (t *tableRef) relationName() string {
+	if t.alias != "" {
+		return t.alias
+	}
+	return t.name
+}
+
+// join adds the columns of another relation, matching rows on the conditions.
+//
+// Syntax: "join {? side:{inner|left|right|full}} {relation} [{condition},...]"
+//
+// The relation is either a table name, or a parenthesised pipeline, and may be
+// given an alias with "alias = relation". A condition that is only a column
+// name, or "==column", matches the column of the same name in both relations.
+// Conditions of only names join with USING, where the dialect has it.
+func (r *relation) join(call syntax.Call) error {
+	if len(call.Args) != 2 {
+		return errorf(call.Pos(), "'join' expects a relation and the conditions to join on")
+	}
+
+	side := "JOIN"
+	for name, value := range call.Named {
+		if name != "side" {
+			return errorf(value.Pos(), "'join' has no parameter named '%s'", name)
+		}
+		ident, ok := value.(syntax.Ident)
+		if ok {
+			side, ok = joinSides[ident.Name]
+		}
+		if !ok {
+			return errorf(value.Pos(), "'side' expects inner, left, right, or full but found %s", value.String())
+		}
+	}
+
+	// Only a single FULL JOIN is emulated within each query, which does not
+	// keep the order of the rows
+	rightJoins := r.c.target.Supports(CapabilityRightJoin)
+	if side == "FULL JOIN" && !r.c.target.Supports(CapabilityFullJoin) {
+		if r.emulatesFullJoin() || (!rightJoins && len(r.query.joins) > 0) {
+			r.split()
+		}
+		r.sort = nil
+	}
+
+	// A RIGHT JOIN is emulated by swapping the relations, so the earlier joins
+	// must be within a subquery
+	if side == "RIGHT JOIN" && !rightJoins && len(r.query.joins) > 0 {
+		r.split()
+	}
+
+	// Joins are applied before filtering, so only the rows kept by an inner or
+	// left join are the same once filtered afterwards.
+	if r.stage > stageWhere || r.hasWindow() ||
+		(r.stage == stageWhere && side != "JOIN" && side != "LEFT JOIN") {
+		r.split()
+	}
+
+	table, err := r.joinTable(call.Args[0])
+	if err != nil {
+		return err
+	}
+
+	left := r.query.from.relationName()
+	right := table.relationName()
+	clause := &joinClause{side: side, table: table}
+
+	conditions := listItems(call.Args[1])
+	allNames := true
+	for _, cond := range conditions {
+		if _, ok := cond.(syntax.Ident); !ok {
+			allNames = false
+		}
+	}
+	if allNames && r.c.target.Supports(CapabilityJoinUsing) {
+		for _, cond := range conditions {
+			clause.using = append(clause.using, cond.(syntax.Ident).Name)
+		}
+	}
+
+	// Columns joined with USING are shared by both relations, so stay
+	// unqualified. After an earlier join, an unqualified name may belong to
+	// any of the relations, so is left as it is.
+	joined := len(r.query.joins) > 0
+	if !joined {
+		r.qualify(left, clause.using)
+	}
+
+	// The right relation is in scope while translating the conditions
+	rightStar := &column{expr: starExpr{relation: right}}
+	r.columns = append(r.columns, rightStar)
+
+	for _, cond := range conditions {
+		if len(clause.using) > 0 {
+			break
+		}
+
+		var expr sqlExpr
+		if name, ok := sharedColumn(cond); ok {
+			var leftExpr sqlExpr = columnRef{left, name}
+			if joined {
+				if leftExpr, err = r.sharedColumnAfterJoin(cond, name); err != nil {
+					return err
+				}
+			}
+			expr = binaryExpr{"=", leftExpr, columnRef{right, name}}
+		} else if expr, err = r.translate(cond); err != nil {
+			return err
+		}
+		clause.on = and(clause.on, expr)
+	}
+
+	r.query.joins = append(r.query.joins, clause)
+	return nil
+}
+
+// emulatesFullJoin returns true if the query has a FULL JOIN, which the
+// dialect does not. Only the transforms which apply to each row, such as
+// "filter" and "derive", may follow it within the same query.
+func (r *relation) emulatesFullJoin() bool {
+	return r.query.fullJoin() != nil && !r.c.target.Supports(CapabilityFullJoin)
+}
+
+// fullJoin returns the FULL JOIN of the query, if any.
+func (q *selectQuery) fullJoin() *joinClause {
+	for _, join := range q.joins {
+		if join.side == "FULL JOIN" {
+			return join
+		}
+	}
+	return nil
+}
+
+// fullJoinUnion emulates the FULL JOIN of the query as the rows of a LEFT
+// JOIN, and the rows of a RIGHT JOIN which do not match the condition. Those
+// are the rows joined to nulls, for which the condition is null. Rows joined
+// by USING have nulls in place of the shared columns of the left relation.
+//
+// Without CapabilityRightJoin, the FULL JOIN must be the first join of the
+// query, so the RIGHT JOIN may be swapped into a LEFT JOIN.
+func fullJoinUnion(q *selectQuery) *selectQuery {
+	full := q.fullJoin()
+	left, right := *q, *q
+	left.joins = make([]*joinClause, len(q.joins))
+	right.joins = make([]*joinClause, len(q.joins))
+	for i, join := range q.joins {
+		left.joins[i], right.joins[i] = join, join
+	}
+
+	leftJoin, rightJoin := *full, *full
+	leftJoin.side, rightJoin.side = "LEFT JOIN", "RIGHT JOIN"
+	for i, join := range q.joins {
+		if join == full {
+			left.joins[i], right.joins[i] = &leftJoin, &rightJoin
+		}
+	}
+
+	unmatched := binaryExpr{"IS NOT", full.on, literalExpr{syntax.TypeBoolean, "true"}}
+	if len(full.using) > 0 {
+		unmatched = binaryExpr{"IS", columnRef{q.from.relationName(), full.using[0]}, literalExpr{syntax.TypeUnknown, "null"}}
+	}
+	right.where = and(q.where, unmatched)
+
+	left.compound = []setOperation{{op: "UNION ALL", query: &right}}
+	return &left
+}
+
+// qualify references the columns of the relation by the relation name, so
+// they are not ambiguous with the columns of a joined relation. The shared
+// columns are left unqualified.
+func (r *relation) qualify(relation string, shared []string) {
+	isShared := func(name string) bool {
+		for _, col := range shared {
+			if col == name {
+				return true
+			}
+		}
+		return false
+	}
+	qualified := func(expr sqlExpr) sqlExpr {
+		return mapExpr(expr, func(e sqlExpr) (sqlExpr, bool) {
+			if ref, ok := e.(columnRef); ok && ref.relation == "" && !isShared(ref.name) {
+				return columnRef{relation, ref.name}, true
+			}
+			return e, false
+		})
+	}
+
+	columns := make([]*column, len(r.columns))
+	for i, col := range r.columns {
+		copied := *col
+		copied.expr = qualified(col.expr)
+		columns[i] = &copied
+	}
+	r.columns = columns
+
+	sort := make([]sortItem, len(r.sort))
+	for i, item := range r.sort {
+		sort[i] = sortItem{qualified(item.expr), item.desc, item.nulls}
+	}
+	r.sort = sort
+}
+
+// sharedColumn returns the name of the column for the "==column" shorthand, or
+// a column name used alongside other conditions.
+func sharedColumn(cond syntax.Expr) (string, bool) {
+	if unary, ok := cond.(syntax.Unary); ok && unary.Op == "==" {
+		if ident, ok := unary.Operand.(syntax.Ident); ok {
+			return ident.Name, true
+		}
+	}
+	if ident, ok := cond.(syntax.Ident); ok {
+		return ident.Name, true
+	}
+	return "", false
+}
+
+// sharedColumnAfterJoin returns the left side of the "==column" shorthand
+// after an earlier join, which is only known when the column was qualified
+// with it's relation before that join.
+func (r *relation) sharedColumnAfterJoin(cond syntax.Expr, name string) (sqlExpr, error) {
+	if col := r.lookup(name); col != nil {
+		if ref, ok := col.expr.(columnRef); !ok || ref.relation != "" {
+			return col.expr, nil
+		}
+	}
+	return nil, errorf(cond.Pos(), "column '%s' may belong to any of the joined relations, so must be qualified with it's relation", name)
+}
+
+// joinTable builds the relation being joined, which is either a table name or
+// a parenthesised pipeline.
+func (r *relation) joinTable(arg syntax.Expr) (*tableRef, error) {
+	alias, value := itemName(arg)
+
+	switch v := value.(type) {
+	case syntax.Ident:
+		if _, err := r.c.useTable(v); err != nil {
+			return nil, err
+		}
+		ref := &tableRef{name: v.Name}
+		if _, isAssign := arg.(syntax.Assign); isAssign {
+			ref.alias = alias
+		}
+		return ref, nil
+
+	case syntax.Pipeline, syntax.Call:
+		stages := pipelineStages(v)
+		sub, err := r.c.compilePipeline(&syntax.Pipeline{Position: v.Pos(), Stages: stages})
+		if err != nil {
+			return nil, err
+		}
+
+		// A pipeline of only a table is the same as the table itself
+		if len(stages) == 1 && sub.query.from.subquery == nil {
+			ref := *sub.query.from
+			if alias != "" {
+				ref.alias = alias
+			}
+			return &ref, nil
+		}
+
+		if alias == "" {
+			alias = r.c.nextTableName()
+		}
+		return r.c.subquery(alias, sub.finish(false)), nil
+	}
+
+	return nil, errorf(value.Pos(), "'join' expects a table name or pipeline but found %s", value.String())
+}
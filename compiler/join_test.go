@@ -0,0 +1,177 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileJoin(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "using",
+			source: `
+from employees
+join side:left countries [country_code]`,
+			expected: `
+SELECT employees.*, countries.*
+FROM employees
+LEFT JOIN countries USING (country_code)`,
+		},
+		{
+			name: "shorthand with conditions",
+			source: `
+from e = employees
+join c = countries [==country_code, e.started > c.founded]`,
+			expected: `
+SELECT e.*, c.*
+FROM employees AS e
+JOIN countries AS c ON e.country_code = c.country_code AND e.started > c.founded`,
+		},
+		{
+			name: "pipeline",
+			source: `
+from employees
+filter active
+join d = (from departments | filter open | select [id, name]) [employees.dept_id == d.id]`,
+			expected: `
+SELECT employees.*, d.*
+FROM employees
+JOIN (
+  SELECT id, name
+  FROM departments
+  WHERE open
+) AS d ON employees.dept_id = d.id
+WHERE active`,
+		},
+		{
+			name: "after aggregate",
+			source: `
+from employees
+group country_code (aggregate [n = count])
+join side:right countries [country_code]`,
+			expected: `
+SELECT country_code, table_0.n, countries.*
+FROM (
+  SELECT country_code, COUNT(*) AS n
+  FROM employees
+  GROUP BY country_code
+) AS table_0
+RIGHT JOIN countries USING (country_code)`,
+		},
+		{
+			name: "full after filter",
+			source: `
+from employees
+filter active
+join side:full countries [==country_code]`,
+			expected: `
+SELECT table_0.*, countries.*
+FROM (
+  SELECT *
+  FROM employees
+  WHERE active
+) AS table_0
+FULL JOIN countries ON table_0.country_code = countries.country_code`,
+		},
+		{
+			name: "qualifies known columns",
+			source: `
+from t
+select [id, name, total = price * qty]
+sort total
+join u [==id]`,
+			expected: `
+SELECT t.id, t.name, t.price * t.qty AS total, u.*
+FROM t
+JOIN u ON t.id = u.id
+ORDER BY t.price * t.qty`,
+		},
+		{
+			name: "second join leaves names unqualified",
+			source: `
+from e
+join c [==id]
+select [name, cx]
+join d [cx == d.k]`,
+			expected: `
+SELECT name, cx, d.*
+FROM e
+JOIN c ON e.id = c.id
+JOIN d ON cx = d.k`,
+		},
+		{
+			name: "second join of a qualified column",
+			source: `
+from e
+select [id, k, name]
+join c [==id]
+join d [==k]`,
+			expected: `
+SELECT e.id, e.k, e.name, c.*, d.*
+FROM e
+JOIN c ON e.id = c.id
+JOIN d ON e.k = d.k`,
+		},
+	})
+}
+
+func TestCompileFullJoinEmulated(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "on condition",
+			source: `prql dialect:mysql
+from e
+join side:full c [e.id == c.id]
+filter e.active
+select [e.name, c.total]`,
+			expected: `
+SELECT e.name, c.total
+FROM e
+LEFT JOIN c ON e.id = c.id
+WHERE e.active
+UNION ALL
+SELECT e.name, c.total
+FROM e
+RIGHT JOIN c ON e.id = c.id
+WHERE e.active AND (e.id = c.id) IS NOT TRUE`,
+		},
+		{
+			name: "using",
+			source: `prql dialect:mysql
+from e
+join side:full c [id]`,
+			expected: `
+SELECT e.*, c.*
+FROM e
+LEFT JOIN c USING (id)
+UNION ALL
+SELECT e.*, c.*
+FROM e
+RIGHT JOIN c USING (id)
+WHERE e.id IS NULL`,
+		},
+		{
+			name: "sorted within a subquery",
+			source: `prql dialect:mysql
+from e
+join side:full c [id]
+sort id`,
+			expected: `
+SELECT *
+FROM (
+  SELECT e.*, c.*
+  FROM e
+  LEFT JOIN c USING (id)
+  UNION ALL
+  SELECT e.*, c.*
+  FROM e
+  RIGHT JOIN c USING (id)
+  WHERE e.id IS NULL
+) AS table_0
+ORDER BY id`,
+		},
+	})
+}
+
+func TestCompileJoinErrors(t *testing.T) {
+	compileError(t, "from e\njoin side:outer c [id]", "'side' expects inner, left, right, or full")
+	compileError(t, "from e\njoin c", "'join' expects a relation and the conditions")
+	compileError(t, "from e\njoin c [==id]\nselect [name, cx]\njoin d [==cx]", "4:9: column 'cx' may belong to any of the joined relations, so must be qualified with it's relation")
+}
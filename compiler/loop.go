@@ -0,0 +1,94 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// loop repeatedly applies a nested pipeline to the rows it last produced,
+// starting with the rows of the relation, until no more rows are produced.
+// The result is every row produced along the way.
+//
+// This is compiled to a recursive common table, where the relation is the
+// anchor query, and the pipeline the recursive query reading from the table.
+// Dialects only allow the table to be read directly by the recursive query,
+// so a pipeline needing a subquery cannot be compiled.
+func (r *relation) loop(call syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+	if !r.c.target.Supports(CapabilityRecursiveTables) {
+		return r.c.unsupported(call.Pos(), "'loop'")
+	}
+
+	if r.stage >= stageDistinct {
+		r.split()
+	}
+	anchor := r.finishTable()
+
+	// The columns of the recursive query are qualified, as it is likely to
+	// join with tables having the same columns
+	name := r.c.nextTableName()
+	from := &tableRef{name: name}
+	step := &relation{
+		c:       r.c,
+		query:   newSelectQuery(from),
+		columns: r.tableColumns(name),
+	}
+	for _, col := range step.columns {
+		if ref, ok := col.expr.(columnRef); ok {
+			col.expr = columnRef{name, ref.name}
+		}
+	}
+	r.c.loops++
+	defer func() { r.c.loops-- }()
+	for _, stage := range pipelineStages(arg) {
+		transform, ok := stage.(syntax.Call)
+		if !ok {
+			return errorf(stage.Pos(), "expected a transform but found %s", stage.String())
+		}
+		if err := step.transform(transform); err != nil {
+			return err
+		}
+		if step.query.from != from {
+			return r.c.unsupported(transform.Pos(), "a subquery for '"+transform.Name+"'")
+		}
+	}
+	if err := r.checkOperands(call, step); err != nil {
+		return err
+	}
+
+	recursive := step.operand()
+	if recursive.from != from {
+		return r.c.unsupported(call.Pos(), "a subquery for the recursive query")
+	}
+	anchor.compound = []setOperation{{op: "UNION ALL", query: recursive}}
+	r.c.tables = append(r.c.tables, &commonTable{name: name, query: anchor, recursive: true})
+
+	r.query = newSelectQuery(&tableRef{name: name})
+	r.columns = r.tableColumns(name)
+	r.stage = stageFrom
+	return nil
+}
+
+// tableColumns returns the columns of the relation as read from a table of
+// the given name, which holds the results of the relation.
+func (r *relation) tableColumns(name string) []*column {
+	hasStar := r.hasStar()
+
+	var columns []*column
+	if hasStar {
+		columns = append(columns, &column{expr: starExpr{relation: name}})
+	}
+	for _, col := range r.columns {
+		if col.isStar() || col.hidden {
+			continue
+		}
+		columns = append(columns, &column{
+			name:    col.name,
+			expr:    columnRef{name: col.name},
+			covered: hasStar,
+		})
+	}
+	return columns
+}
@@ -0,0 +1,81 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileLoop(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "counter",
+			source: `
+from seed
+select [n = 1]
+loop (filter n < 4 | select [n = n + 1])
+filter n > 1`,
+			expected: `
+WITH RECURSIVE table_0 AS (
+  SELECT 1 AS n
+  FROM seed
+  UNION ALL
+  SELECT table_0.n + 1 AS n
+  FROM table_0
+  WHERE table_0.n < 4
+)
+SELECT n
+FROM table_0
+WHERE n > 1`,
+		},
+		{
+			name: "unknown columns",
+			source: `
+from tree
+loop (filter parent != null)
+take 5`,
+			expected: `
+WITH RECURSIVE table_0 AS (
+  SELECT *
+  FROM tree
+  UNION ALL
+  SELECT *
+  FROM table_0
+  WHERE parent IS NOT NULL
+)
+SELECT *
+FROM table_0
+LIMIT 5`,
+		},
+		{
+			name: "mssql org chart",
+			source: `
+prql dialect:mssql
+from employees
+filter manager_id == null
+select [id, name, level = 0]
+loop (
+  join e = employees [e.manager_id == id]
+  select [e.id, e.name, level = level + 1]
+)`,
+			expected: `
+WITH table_0 AS (
+  SELECT id, name, 0 AS level
+  FROM employees
+  WHERE manager_id IS NULL
+  UNION ALL
+  SELECT e.id, e.name, table_0.level + 1 AS level
+  FROM table_0
+  JOIN employees AS e ON e.manager_id = table_0.id
+)
+SELECT id, name, level
+FROM table_0`,
+		},
+	})
+}
+
+func TestCompileLoopErrors(t *testing.T) {
+	compileError(t, "prql dialect:hive\nfrom a\nloop (filter n < 4)", "3:1: 'loop' is not supported by dialect hive")
+	compileError(t, "prql dialect:clickhouse\nfrom a\nloop (filter n < 4)", "3:1: 'loop' is not supported by dialect clickhouse")
+	compileError(t, "from a\nloop", "2:1: 'loop' expects 1 argument but received 0")
+	compileError(t, "from a\nselect [n = 1]\nloop (select [n = \"x\"])", "3:7: 'loop' expects column 1 to be integer but found string")
+	compileError(t, "from a\nselect [n = 1]\nloop (select [n, m = 2])", "3:7: 'loop' expects a relation of 1 columns but found 2")
+	compileError(t, "from a\nloop (take 1 | aggregate [n = count])", "2:16: a subquery for 'aggregate' in 'loop' is not supported by dialect generic")
+	compileError(t, "prql dialect:sqlite\nfrom a\nloop (sort n | take 1)", "3:1: a subquery for the recursive query in 'loop' is not supported by dialect sqlite")
+}
@@ -0,0 +1,15 @@
+package compiler
+
+import "strings"
+
+// dateAdd returns the template adding the value in units to the date "{0}",
+// or subtracting it when negate is true, as "DATEADD(unit, value, date)". MSSQL
+// has no interval type, so this is used in place of the "+" and "-" operators.
+func dateAdd(value, unit string, negate bool) string {
+	if negate && strings.HasPrefix(value, "-") {
+		value = value[1:]
+	} else if negate {
+		value = "-" + value
+	}
+	return "DATEADD(" + unit + ", " + value + ", {0})"
+}
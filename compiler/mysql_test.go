@@ -0,0 +1,69 @@
+package compiler_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+)
+
+func TestCompileMySQLVersions(t *testing.T) {
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "intersect",
+			source: `prql dialect:mysql
+from a
+intersect b`,
+			expected: `
+SELECT *
+FROM a
+INTERSECT
+SELECT *
+FROM b`,
+		},
+	}, compiler.Options{MySQL: compiler.MySQL8031})
+
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "splits without common tables",
+			source: `prql dialect:mysql
+from a
+take 10
+filter x > 1`,
+			expected: `
+SELECT *
+FROM (
+  SELECT *
+  FROM a
+  LIMIT 10
+) AS table_0
+WHERE x > 1`,
+		},
+	}, compiler.Options{MySQL: compiler.MySQL57, Splits: compiler.SplitCommonTables})
+}
+
+func TestCompileMySQL57Errors(t *testing.T) {
+	tests := map[string]string{
+		"from t\nderive [r = rank]":                      "3:13: window function 'rank' in 'derive' is not supported by dialect mysql 5.7",
+		"from t\nwindow rows:-2..0 (derive [s = sum x])": "3:27: aggregate functions over a window in 'window' is not supported by dialect mysql 5.7",
+		"from t\ngroup id (take 1)":                      "window function 'row_number' in 'group' is not supported by dialect mysql 5.7",
+		"table a = (from t)\nfrom a":                     "3:6: declared table 'a' in 'from' is not supported by dialect mysql 5.7",
+		"from t\nloop (filter n < 5)":                    "'loop' is not supported by dialect mysql 5.7",
+	}
+
+	for source, message := range tests {
+		query, err := parser.Parse("prql dialect:mysql\n" + source)
+		if err != nil {
+			t.Fatalf("unexpected parse error: %s", err)
+		}
+		_, err = compiler.Compile(query, compiler.Options{MySQL: compiler.MySQL57})
+
+		var unsupported compiler.UnsupportedError
+		if !errors.As(err, &unsupported) || unsupported.Version != "5.7" {
+			t.Errorf("expected UnsupportedError for MySQL 5.7, received %v", err)
+		} else if msg := err.Error(); msg[len(msg)-len(message):] != message {
+			t.Errorf("expected error ending %q, received %q", message, msg)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package compiler
+
+import "fmt"
+
+// Options holds the settings for compiling a query. The zero-value is the
+// default settings.
+type Options struct {
+	// Nulls declares where null values are placed when sorting. A sort
+	// transform may override this with the "nulls" named parameter, such as
+	// "sort nulls:last [-amount]".
+	Nulls NullsOrder
+
+	// Splits declares how a pipeline which does not fit within a single
+	// SELECT is split, either into nested subqueries or a chain of common
+	// tables of a WITH clause.
+	Splits SplitStyle
+
+	// MySQL declares the version of the MySQL server targeted by the mysql
+	// dialect, as older versions lack some features.
+	MySQL MySQLVersion
+
+	// SQLite declares the version of the SQLite library targeted by the sqlite
+	// dialect, as older versions lack some features.
+	SQLite SQLiteVersion
+
+	// Identifiers declares which names are quoted. Quoting preserves the case
+	// of a name, while the databases which fold the case of names, such as
+	// Snowflake, do so only for those left bare.
+	Identifiers IdentifierStyle
+
+	// SafeDivision results in null rather than an error when dividing by zero,
+	// using "SAFE_DIVIDE" within BigQuery, or "NULLIF" to avoid the zero
+	// otherwise.
+	SafeDivision bool
+}
+
+// SplitStyle is a byte enum declaring how the queries of a split pipeline are
+// combined.
+type SplitStyle byte
+
+const (
+	// SplitSubqueries nests each query within the FROM clause of the next.
+	SplitSubqueries SplitStyle = iota
+
+	// SplitCommonTables declares each query as a common table, which the next
+	// query selects from.
+	SplitCommonTables
+)
+
+// holds SplitStyle -> string mapping
+var splitStyleStringMap = map[SplitStyle]string{
+	SplitSubqueries:   "subqueries",
+	SplitCommonTables: "ctes",
+}
+
+// String returns the string representation of the SplitStyle enum. If
+// invalid, defaults to returning "subqueries".
+func (s SplitStyle) String() string {
+	if str, ok := splitStyleStringMap[s]; ok {
+		return str
+	}
+	return "subqueries"
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Accepts
+// "subqueries" or "ctes".
+func (s *SplitStyle) UnmarshalText(text []byte) error {
+	str := string(text)
+	for style, name := range splitStyleStringMap {
+		if name == str {
+			*s = style
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid SplitStyle '%s'", str)
+}
+
+// MySQLVersion is a byte enum of the MySQL server versions which differ in the
+// SQL they accept.
+type MySQLVersion byte
+
+const (
+	// MySQL80 is MySQL 8.0, which has window functions and common tables.
+	MySQL80 MySQLVersion = iota
+
+	// MySQL8031 is MySQL 8.0.31 or later, which adds the "INTERSECT" and
+	// "EXCEPT" set operators.
+	MySQL8031
+
+	// MySQL57 is MySQL 5.7, without window functions or common tables.
+	MySQL57
+)
+
+// holds MySQLVersion -> string mapping
+var mySQLVersionStringMap = map[MySQLVersion]string{
+	MySQL80:   "8.0",
+	MySQL8031: "8.0.31",
+	MySQL57:   "5.7",
+}
+
+// String returns the string representation of the MySQLVersion enum. If
+// invalid, defaults to returning "8.0".
+func (v MySQLVersion) String() string {
+	if str, ok := mySQLVersionStringMap[v]; ok {
+		return str
+	}
+	return "8.0"
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Accepts
+// "8.0", "8.0.31" or "5.7".
+func (v *MySQLVersion) UnmarshalText(text []byte) error {
+	str := string(text)
+	for version, name := range mySQLVersionStringMap {
+		if name == str {
+			*v = version
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid MySQLVersion '%s'", str)
+}
+
+// SQLiteVersion is a byte enum of the SQLite library versions which differ in
+// the SQL they accept.
+type SQLiteVersion byte
+
+const (
+	// SQLite339 is SQLite 3.39 or later, which has every join.
+	SQLite339 SQLiteVersion = iota
+
+	// SQLite325 is SQLite 3.25, the first with window functions, but without
+	// "RIGHT JOIN", "FULL JOIN", "NULLS FIRST" or aggregate filters.
+	SQLite325
+)
+
+// holds SQLiteVersion -> string mapping
+var sqliteVersionStringMap = map[SQLiteVersion]string{
+	SQLite339: "3.39",
+	SQLite325: "3.25",
+}
+
+// String returns the string representation of the SQLiteVersion enum. If
+// invalid, defaults to returning "3.39".
+func (v SQLiteVersion) String() string {
+	if str, ok := sqliteVersionStringMap[v]; ok {
+		return str
+	}
+	return "3.39"
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Accepts
+// "3.39" or "3.25".
+func (v *SQLiteVersion) UnmarshalText(text []byte) error {
+	str := string(text)
+	for version, name := range sqliteVersionStringMap {
+		if name == str {
+			*v = version
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid SQLiteVersion '%s'", str)
+}
+
+// IdentifierStyle is a byte enum declaring which identifiers are quoted.
+type IdentifierStyle byte
+
+const (
+	// IdentifiersDefault quotes only the names which are not lower case, or
+	// are reserved words.
+	IdentifiersDefault IdentifierStyle = iota
+
+	// IdentifiersQuoted quotes every name, preserving it's case.
+	IdentifiersQuoted
+
+	// IdentifiersBare leaves every name bare which the dialect allows, so the
+	// database folds it's case. Reserved words are still quoted, in the case
+	// they would be folded into.
+	IdentifiersBare
+)
+
+// holds IdentifierStyle -> string mapping
+var identifierStyleStringMap = map[IdentifierStyle]string{
+	IdentifiersDefault: "default",
+	IdentifiersQuoted:  "quoted",
+	IdentifiersBare:    "bare",
+}
+
+// String returns the string representation of the IdentifierStyle enum. If
+// invalid, defaults to returning "default".
+func (s IdentifierStyle) String() string {
+	if str, ok := identifierStyleStringMap[s]; ok {
+		return str
+	}
+	return "default"
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Accepts
+// "default", "quoted" or "bare".
+func (s *IdentifierStyle) UnmarshalText(text []byte) error {
+	str := string(text)
+	for style, name := range identifierStyleStringMap {
+		if name == str {
+			*s = style
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid IdentifierStyle '%s'", str)
+}
@@ -0,0 +1,36 @@
+// Package compiler is responsible for taking a parsed PRQL query (AST) and
+// generating the SQL statement for it in the dialect requested by the query
+// header.
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// Compile generates the SQL statement for the given parsed query, using the
+// settings of the given Options.
+//
+// Returns the SQL, or an Error if the query cannot be compiled.
+func Compile(query *syntax.Query, opts Options) (string, error) {
+	sql, _, err := CompileWithWarnings(query, opts)
+	return sql, err
+}
+
+// CompileWithWarnings is the same as Compile, also returning any Warning for
+// parts of the query which are likely mistakes.
+func CompileWithWarnings(query *syntax.Query, opts Options) (string, []Warning, error) {
+	c := newCompiler(query.Header.Dialect, opts)
+	if err := c.declareFunctions(query.Functions); err != nil {
+		return "", nil, err
+	}
+	if err := c.declare(query.Tables); err != nil {
+		return "", nil, err
+	}
+
+	rel, err := c.compilePipeline(query.Main)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return c.generate(rel.finish(true)), c.warnings, nil
+}
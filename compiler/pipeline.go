@@ -0,0 +1,651 @@
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// compiler holds the state for compiling a whole query.
+type compiler struct {
+	dialect syntax.Dialect
+	target  Dialect
+	opts    Options
+
+	tableCount int
+	exprCount  int
+
+	// tables holds the common tables of the WITH clause, in the order they
+	// must be declared
+	tables []*commonTable
+
+	// declared holds the named relations declared by the query
+	declared map[string]*declaredTable
+
+	// loops counts the recursive queries being compiled, which may not be
+	// split into common tables
+	loops int
+
+	// functions holds the functions declared by the query
+	functions map[string]*syntax.Function
+
+	// transform is the name of the transform being compiled
+	transform string
+
+	warnings []Warning
+}
+
+func newCompiler(dialect syntax.Dialect, opts Options) *compiler {
+	target := dialectOf(dialect)
+	if builtin, ok := target.(builtinDialect); ok {
+		builtin.mysql = opts.MySQL
+		builtin.sqlite = opts.SQLite
+		builtin.identifiers = opts.Identifiers
+		target = builtin
+	}
+	return &compiler{dialect: dialect, target: target, opts: opts}
+}
+
+// nextTableName returns a new unique name for a generated relation
+func (c *compiler) nextTableName() string {
+	name := fmt.Sprintf("table_%d", c.tableCount)
+	c.tableCount++
+	return name
+}
+
+// subquery returns the reference to a query used within the FROM or JOIN
+// clause of another, which is declared as a common table when the options
+// ask for it and the dialect has them.
+func (c *compiler) subquery(alias string, query *selectQuery) *tableRef {
+	if c.opts.Splits != SplitCommonTables || c.loops > 0 || !c.target.Supports(CapabilityCommonTables) {
+		return &tableRef{alias: alias, subquery: query}
+	}
+	c.tables = append(c.tables, &commonTable{name: alias, query: query})
+	return &tableRef{name: alias}
+}
+
+// nextExprName returns a new unique name for a generated column
+func (c *compiler) nextExprName() string {
+	name := fmt.Sprintf("_expr_%d", c.exprCount)
+	c.exprCount++
+	return name
+}
+
+// stage is the last clause of a SELECT which has been used by the pipeline,
+// in the order SQL evaluates them. A transform which needs an earlier clause
+// than the current stage requires the query to be split.
+type stage byte
+
+const (
+	stageFrom stage = iota
+	stageWhere
+	stageAggregate
+	stageQualify
+	stageDistinct
+	stageLimit
+
+	// stageCompound is a query combined with others by a set operation, which
+	// any further transform must use as a subquery
+	stageCompound
+)
+
+// column is a single column of the relation produced by the pipeline so far.
+type column struct {
+	// name is empty for computed columns which were not given a name
+	name string
+	expr sqlExpr
+
+	// covered columns are already selected by an earlier star column
+	covered bool
+
+	// hidden columns are only used internally, and are not part of the output
+	hidden bool
+
+	// except holds the names of hidden columns which a star column should not
+	// include, if the dialect allows it
+	except []string
+
+	// excluded holds the names of the columns removed from a star column by
+	// "select ![...]"
+	excluded []string
+}
+
+// isStar returns true if the column selects all columns of a relation.
+func (col *column) isStar() bool {
+	_, ok := col.expr.(starExpr)
+	return ok
+}
+
+// relation holds the state of a pipeline as it is compiled, building up a
+// single SELECT query until a transform requires it to be split.
+type relation struct {
+	c       *compiler
+	query   *selectQuery
+	columns []*column
+	stage   stage
+
+	// sort holds the current order of the rows
+	sort []sortItem
+}
+
+// lookup returns the latest column with the given name, or nil.
+func (r *relation) lookup(name string) *column {
+	for i := len(r.columns) - 1; i >= 0; i-- {
+		if col := r.columns[i]; !col.isStar() && !col.hidden && col.name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// hasStar returns true if any column selects all columns of a relation, in
+// which case unknown names may be columns of it.
+func (r *relation) hasStar() bool {
+	for _, col := range r.columns {
+		if col.isStar() {
+			return true
+		}
+	}
+	return false
+}
+
+// computed returns true if the expression is that of a computed column of
+// the relation.
+func (r *relation) computed(expr sqlExpr) bool {
+	for _, col := range r.columns {
+		if reflect.DeepEqual(col.expr, expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWindow returns true if any column holds a window function.
+func (r *relation) hasWindow() bool {
+	for _, col := range r.columns {
+		if containsWindow(col.expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePipeline compiles a pipeline starting with a "from" transform.
+func (c *compiler) compilePipeline(pipeline *syntax.Pipeline) (*relation, error) {
+	first, ok := pipeline.Stages[0].(syntax.Call)
+	if !ok || first.Name != "from" {
+		return nil, errorf(pipeline.Stages[0].Pos(), "pipeline must start with a 'from' transform")
+	}
+
+	restore := c.within("from")
+	rel, err := c.from(first)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range pipeline.Stages[1:] {
+		call, ok := stage.(syntax.Call)
+		if !ok {
+			return nil, errorf(stage.Pos(), "expected a transform but found %s", stage.String())
+		}
+		if err := rel.transform(call); err != nil {
+			return nil, err
+		}
+	}
+
+	return rel, nil
+}
+
+// from starts a new relation from a table.
+func (c *compiler) from(call syntax.Call) (*relation, error) {
+	if len(call.Args) != 1 {
+		return nil, errorf(call.Pos(), "'from' expects a single table")
+	}
+
+	name, value := itemName(call.Args[0])
+	if rng, ok := value.(syntax.Range); ok {
+		return c.fromSeries(name, rng)
+	}
+	ident, ok := value.(syntax.Ident)
+	if !ok {
+		return nil, errorf(value.Pos(), "'from' expects a table name but found %s", value.String())
+	}
+
+	ref := &tableRef{name: ident.Name}
+	relName := ident.Name
+	if name != ident.Parts()[len(ident.Parts())-1] {
+		ref.alias = name
+		relName = name
+	}
+
+	rel := &relation{
+		c:       c,
+		query:   newSelectQuery(ref),
+		columns: []*column{{expr: starExpr{relation: relName}}},
+	}
+
+	// The columns of declared tables are known
+	declared, err := c.useTable(ident)
+	if err != nil {
+		return nil, err
+	}
+	if declared != nil {
+		rel.columns = declared.tableColumns(relName)
+	}
+	return rel, nil
+}
+
+// fromSeries starts a new relation from the integers of a range, such as
+// "from n = 1..10", within the single column "value".
+func (c *compiler) fromSeries(name string, rng syntax.Range) (*relation, error) {
+	if rng.Start == nil || rng.End == nil {
+		return nil, errorf(rng.Pos(), "'from' expects a range with both a start and an end")
+	}
+	if !c.target.Supports(CapabilitySeries) {
+		return nil, c.unsupported(rng.Pos(), "'from' of a range")
+	}
+
+	var bounds [2]sqlExpr
+	for i, bound := range []syntax.Expr{rng.Start, rng.End} {
+		lit, ok := bound.(syntax.Literal)
+		if !ok || lit.Type != syntax.TypeInteger {
+			return nil, errorf(bound.Pos(), "'from' expects a range of integers but found %s", bound.String())
+		}
+		bounds[i] = literalExpr{lit.Type, lit.Value}
+	}
+
+	if name == "" {
+		name = c.nextTableName()
+	}
+	ref := &tableRef{alias: name, series: &seriesRef{bounds[0], bounds[1], "value"}}
+	return &relation{
+		c:       c,
+		query:   newSelectQuery(ref),
+		columns: []*column{{name: "value", expr: columnRef{name: "value"}}},
+	}, nil
+}
+
+// rowTransforms holds the transforms which apply to each row independently,
+// so may follow an emulated FULL JOIN within the same query.
+var rowTransforms = map[string]bool{
+	"select": true,
+	"derive": true,
+	"filter": true,
+	"join":   true,
+}
+
+// within marks the transform being compiled, returning the function which
+// restores the transform it is nested within.
+func (c *compiler) within(transform string) func() {
+	outer := c.transform
+	c.transform = transform
+	return func() {
+		c.transform = outer
+	}
+}
+
+// transform applies a single transform of the pipeline.
+func (r *relation) transform(call syntax.Call) error {
+	defer r.c.within(call.Name)()
+
+	if _, isSetOp := setOperations[call.Name]; r.stage == stageCompound && !isSetOp {
+		r.split()
+	}
+	if r.emulatesFullJoin() && !rowTransforms[call.Name] {
+		r.split()
+	}
+
+	switch call.Name {
+	case "select":
+		return r.selectColumns(call, nil)
+	case "derive":
+		return r.derive(call, nil)
+	case "filter":
+		return r.filter(call)
+	case "aggregate":
+		return r.aggregate(call, nil)
+	case "group":
+		return r.group(call)
+	case "sort":
+		return r.sortBy(call)
+	case "take":
+		return r.take(call)
+	case "join":
+		return r.join(call)
+	case "window":
+		return r.window(call, nil, nil)
+	case "append", "union", "intersect", "remove":
+		return r.setOperation(call)
+	case "loop":
+		return r.loop(call)
+	case "from":
+		return errorf(call.Pos(), "'from' may only start a pipeline")
+	}
+	return errorf(call.Pos(), "unknown transform '%s'", call.Name)
+}
+
+// singleArg returns the only positional argument of a transform.
+func singleArg(call syntax.Call) (syntax.Expr, error) {
+	if len(call.Args) != 1 {
+		return nil, errorf(call.Pos(), "'%s' expects 1 argument but received %d", call.Name, len(call.Args))
+	}
+	return call.Args[0], nil
+}
+
+// selectColumns replaces the columns of the relation. Any aggregate or window
+// functions are computed over the window given, or the whole relation when nil.
+func (r *relation) selectColumns(call syntax.Call, window *windowSpec) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+
+	if unary, ok := arg.(syntax.Unary); ok && unary.Op == "!" {
+		if list, ok := unary.Operand.(syntax.List); ok {
+			return r.exclude(list)
+		}
+	}
+
+	columns, err := r.computeColumns(arg, window)
+	if err != nil {
+		return err
+	}
+	r.columns = columns
+	return nil
+}
+
+// derive adds new computed columns. Any aggregate or window functions are
+// computed over the window given, or the whole relation when nil.
+func (r *relation) derive(call syntax.Call, window *windowSpec) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+
+	columns, err := r.computeColumns(arg, window)
+	if err != nil {
+		return err
+	}
+	r.columns = append(r.columns, columns...)
+	return nil
+}
+
+// computeColumns translates the columns of a select or derive. Any aggregate
+// or window functions are computed over the window, ordered by it's sort
+// transform or otherwise the current order of the relation.
+func (r *relation) computeColumns(arg syntax.Expr, window *windowSpec) ([]*column, error) {
+	columns, err := r.translateColumns(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns computed earlier are inlined, but already aggregated, so are
+	// skipped when looking for functions which need a window
+	needsWindow := false
+	for _, col := range columns {
+		walkExpr(col.expr, func(e sqlExpr) bool {
+			if r.computed(e) {
+				return false
+			}
+			if fn, ok := e.(funcExpr); ok && (fn.aggregate || fn.window) {
+				needsWindow = true
+			}
+			_, isWindow := e.(windowExpr)
+			return !isWindow
+		})
+	}
+	if !needsWindow {
+		return columns, nil
+	}
+	if !r.c.target.Supports(CapabilityWindows) {
+		return nil, r.c.unsupported(arg.Pos(), "aggregate functions over a window")
+	}
+
+	// Windows are computed before any limit, so apply them to a subquery.
+	// Aggregated rows are also windowed within a subquery, so the aggregated
+	// columns are not themselves made into windows.
+	if r.stage >= stageAggregate || r.emulatesFullJoin() {
+		r.split()
+		if columns, err = r.translateColumns(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if window == nil {
+		window = &windowSpec{}
+	}
+	parts, err := r.translateExprs(window.partition)
+	if err != nil {
+		return nil, err
+	}
+	sort := r.sort
+	if window.order != nil {
+		if sort, err = r.translateSort(window.order); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, col := range columns {
+		col.expr = windowed(col.expr, parts, sort, window.frame)
+	}
+	return columns, nil
+}
+
+// translateExprs translates each of the expressions.
+func (r *relation) translateExprs(exprs []syntax.Expr) ([]sqlExpr, error) {
+	result := make([]sqlExpr, len(exprs))
+	for i, expr := range exprs {
+		_, value := itemName(expr)
+		translated, err := r.translate(value)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = translated
+	}
+	return result, nil
+}
+
+func (r *relation) filter(call syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+
+	cond, err := r.translate(arg)
+	if err != nil {
+		return err
+	}
+
+	// Filters on windows, or after limits, must apply to a subquery. Dialects
+	// with "QUALIFY" filter the windows within the same query instead.
+	window := containsWindow(cond)
+	qualify := window && r.c.target.Supports(CapabilityQualify) && !r.emulatesFullJoin()
+	if (window && !qualify) || r.stage >= stageQualify {
+		r.split()
+		if cond, err = r.translate(arg); err != nil {
+			return err
+		}
+	}
+
+	if qualify {
+		r.query.qualify = and(r.query.qualify, cond)
+		r.stage = stageQualify
+	} else if r.stage == stageAggregate {
+		r.query.having = and(r.query.having, cond)
+	} else {
+		r.query.where = and(r.query.where, cond)
+		r.stage = stageWhere
+	}
+	return nil
+}
+
+// aggregate groups the rows by the given keys, which may be empty to
+// aggregate all rows into one.
+func (r *relation) aggregate(call syntax.Call, keys []syntax.Expr) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+
+	if r.stage >= stageAggregate || r.hasWindow() {
+		r.split()
+	}
+
+	groupBy, err := r.translateExprs(keys)
+	if err != nil {
+		return err
+	}
+	keyColumns := make([]*column, len(keys))
+	for i, key := range keys {
+		name, _ := itemName(key)
+		keyColumns[i] = &column{name: name, expr: groupBy[i]}
+	}
+
+	columns, err := r.translateColumns(arg)
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		if containsWindow(col.expr) {
+			return errorf(arg.Pos(), "window functions cannot be used within 'aggregate'")
+		}
+	}
+
+	r.query.groupBy = groupBy
+	r.columns = append(keyColumns, columns...)
+	r.stage = stageAggregate
+
+	// The order of the rows is lost once they are aggregated
+	r.sort = nil
+	return nil
+}
+
+// finish builds the SELECT query for the relation. When final is false, the
+// query is being used as a subquery, so each computed column is given a name
+// which the outer query may reference.
+func (r *relation) finish(final bool) *selectQuery {
+	query := r.query
+	query.columns = nil
+
+	for _, col := range r.columns {
+		if col.covered || (final && col.hidden) {
+			continue
+		}
+
+		if col.name == "" && !col.isStar() && !final {
+			col.name = r.c.nextExprName()
+		}
+
+		item := selectItem{expr: col.expr}
+		if ref, ok := col.expr.(columnRef); !ok || ref.name != col.name {
+			item.alias = col.name
+		}
+
+		// Hidden columns of an earlier query are only excluded from the final
+		// one, as the queries between may still use them
+		if star, ok := col.expr.(starExpr); ok {
+			star.except = col.excluded
+			if final && !query.distinct && r.c.target.Supports(CapabilityStarExcept) {
+				star.except = append(star.except[:len(star.except):len(star.except)], col.except...)
+			}
+			item.expr = star
+		}
+		query.columns = append(query.columns, item)
+	}
+
+	// A single star does not need to be qualified
+	if len(query.columns) == 1 {
+		if star, ok := query.columns[0].expr.(starExpr); ok {
+			query.columns[0].expr = starExpr{except: star.except}
+		}
+	}
+
+	if final && query.orderBy == nil {
+		query.orderBy = r.sort
+	}
+
+	if r.emulatesFullJoin() {
+		return fullJoinUnion(query)
+	}
+	return query
+}
+
+// finishTable builds the query of the relation for use as a named table.
+// Hidden columns are removed, and the order of the rows is not kept.
+func (r *relation) finishTable() *selectQuery {
+	columns := make([]*column, 0, len(r.columns))
+	for _, col := range r.columns {
+		if !col.hidden {
+			columns = append(columns, col)
+		}
+	}
+	r.columns = columns
+	r.sort = nil
+	return r.finish(false)
+}
+
+// split finishes the current query and uses it as the subquery of a new one.
+// The columns of the relation become references to the columns of the
+// subquery.
+func (r *relation) split() {
+	hasStar := r.hasStar()
+
+	// Keep the order of rows, exposing any sorted values which would not
+	// otherwise be available to the outer query
+	sort := make([]sortItem, len(r.sort))
+	for i, item := range r.sort {
+		expr := r.outerExpr(item.expr, hasStar)
+		if expr == nil {
+			name := r.c.nextExprName()
+			r.columns = append(r.columns, &column{name: name, expr: item.expr, hidden: true})
+			expr = columnRef{name: name}
+		}
+		sort[i] = sortItem{expr, item.desc, item.nulls}
+	}
+
+	inner := r.finish(false)
+	alias := r.c.nextTableName()
+	r.query = newSelectQuery(r.c.subquery(alias, inner))
+
+	var except []string
+	for _, col := range r.columns {
+		if col.hidden {
+			except = append(except, col.name)
+		}
+	}
+
+	columns := make([]*column, 0, len(r.columns))
+	if hasStar {
+		columns = append(columns, &column{expr: starExpr{relation: alias}, except: except})
+	}
+	for _, col := range r.columns {
+		if col.isStar() || col.hidden {
+			continue
+		}
+		columns = append(columns, &column{
+			name:    col.name,
+			expr:    columnRef{name: col.name},
+			covered: hasStar,
+		})
+	}
+
+	r.columns = columns
+	r.sort = sort
+	r.stage = stageFrom
+}
+
+// outerExpr finds the expression referencing the same value within the outer
+// query after a split, or nil if it is not available.
+func (r *relation) outerExpr(expr sqlExpr, hasStar bool) sqlExpr {
+	for _, col := range r.columns {
+		if !col.isStar() && !col.hidden && reflect.DeepEqual(col.expr, expr) {
+			return columnRef{name: col.name}
+		}
+	}
+	if ref, ok := expr.(columnRef); ok && hasStar && !r.excluded(ref.name) {
+		return columnRef{name: ref.name}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package compiler
+
+// generateSeries renders the table function of the integers within a series,
+// which is only compiled for dialects with CapabilitySeries, such as Postgres.
+func (g *generator) generateSeries(series *seriesRef) string {
+	return "GENERATE_SERIES(" + g.expr(series.start) + ", " + g.expr(series.end) + ")"
+}
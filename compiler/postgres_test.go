@@ -0,0 +1,45 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileAggregateFilterEmulated(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "generic",
+			source: `
+from orders
+aggregate [paid = count filter:(status == "paid"), total = sum amount filter:(status == "paid")]`,
+			expected: `
+SELECT COUNT(CASE WHEN status = 'paid' THEN 1 END) AS paid, SUM(CASE WHEN status = 'paid' THEN amount END) AS total
+FROM orders`,
+		},
+		{
+			name: "sqlite",
+			source: `prql dialect:sqlite
+from orders
+aggregate [paid = count filter:(status == "paid")]`,
+			expected: `
+SELECT COUNT(*) FILTER (WHERE status = 'paid') AS paid
+FROM orders`,
+		},
+		{
+			name: "ignore case without ilike",
+			source: `
+from customers
+filter (text.contains "Smith" name ignore_case:true)`,
+			expected: `
+SELECT *
+FROM customers
+WHERE LOWER(name) LIKE '%smith%'`,
+		},
+	})
+}
+
+func TestCompilePostgresErrors(t *testing.T) {
+	compileError(t, "from t\naggregate [s = sum x filter:1]", "function 'sum' expects the filter to be boolean but found integer")
+	compileError(t, "from t\nderive [l = text.lower name filter:ok]", "2:36: function 'text.lower' has no parameter named 'filter'")
+	compileError(t, "from t\nfilter (text.contains \"a\" name ignore_case:1)", "function 'text.contains' expects ignore_case to be true or false")
+	compileError(t, "prql dialect:postgres\nfrom n = 1..", "'from' expects a range with both a start and an end")
+	compileError(t, "prql dialect:postgres\nfrom n = 1..x", "'from' expects a range of integers but found x")
+	compileError(t, "from n = 1..10", "'from' of a range is not supported by dialect generic")
+}
@@ -0,0 +1,16 @@
+package compiler
+
+import "github.com/chris-pikul/go-prql/syntax"
+
+// regexMatch translates the "~=" operator, which is true when the text
+// contains a match of the regular expression. Dialects without their own
+// template use "REGEXP".
+func (c *compiler) regexMatch(pos syntax.Position, text, pattern sqlExpr) (sqlExpr, error) {
+	if !c.target.Supports(CapabilityRegex) {
+		return nil, c.unsupported(pos, "regular expression operator '~='")
+	}
+	if template, ok := c.target.Function("~=", ""); ok {
+		return funcExpr{template: template, args: []sqlExpr{text, pattern}, typ: syntax.TypeBoolean}, nil
+	}
+	return binaryExpr{"REGEXP", text, pattern}, nil
+}
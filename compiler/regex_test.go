@@ -0,0 +1,64 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileRegex(t *testing.T) {
+	pipeline := `
+from t
+filter (name ~= "^a" and !(code ~= "x"))`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "generic",
+			source: pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE name REGEXP '^a' AND NOT code REGEXP 'x'`,
+		},
+		{
+			name:   "postgres",
+			source: "prql dialect:postgres" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE name ~ '^a' AND NOT code ~ 'x'`,
+		},
+		{
+			name:   "hive",
+			source: "prql dialect:hive" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE name RLIKE '^a' AND NOT code RLIKE 'x'`,
+		},
+		{
+			name:   "bigquery",
+			source: "prql dialect:bigquery" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE REGEXP_CONTAINS(name, '^a') AND NOT REGEXP_CONTAINS(code, 'x')`,
+		},
+		{
+			name:   "snowflake",
+			source: "prql dialect:snowflake" + pipeline,
+			expected: `
+SELECT *
+FROM t
+WHERE REGEXP_INSTR(name, '^a') > 0 AND NOT REGEXP_INSTR(code, 'x') > 0`,
+		},
+		{
+			name: "as a value",
+			source: `prql dialect:mysql
+from t
+derive [matched = (name ~= "[0-9]+")]`,
+			expected: `
+SELECT t.*, name REGEXP '[0-9]+' AS matched
+FROM t`,
+		},
+	})
+
+	compileError(t, "prql dialect:sqlite\nfrom t\nfilter (name ~= \"^a\")", "3:9: regular expression operator '~=' in 'filter' is not supported by dialect sqlite")
+	compileError(t, "prql dialect:mssql\nfrom t\nfilter (name ~= \"^a\")", "3:9: regular expression operator '~=' in 'filter' is not supported by dialect mssql")
+}
@@ -0,0 +1,173 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// setOperations maps the set transforms to their SQL operator
+var setOperations = map[string]string{
+	"append":    "UNION ALL",
+	"union":     "UNION",
+	"intersect": "INTERSECT",
+	"remove":    "EXCEPT",
+}
+
+// setOperation combines the rows of the relation with those of another, which
+// is either a table name or a parenthesised pipeline.
+//
+// The relations must have the same number of columns, with compatible types,
+// whenever their columns are known.
+func (r *relation) setOperation(call syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+	op := setOperations[call.Name]
+
+	right, err := r.operandRelation(arg)
+	if err != nil {
+		return err
+	}
+	if err := r.checkOperands(call, right); err != nil {
+		return err
+	}
+
+	// Without "INTERSECT" and "EXCEPT" the rows are filtered by "EXISTS"
+	// subqueries instead
+	if (op == "INTERSECT" || op == "EXCEPT") && !r.c.target.Supports(CapabilityIntersectExcept) {
+		return r.existsOperation(call, op, right)
+	}
+
+	// Only the same operator may be chained within one query, since
+	// "INTERSECT" is evaluated before the others
+	if r.stage == stageCompound && r.query.compound[len(r.query.compound)-1].op != op {
+		r.split()
+	}
+	if r.stage != stageCompound {
+		r.operand()
+	}
+
+	r.query.compound = append(r.query.compound, setOperation{op: op, query: right.operand()})
+	r.stage = stageCompound
+	return nil
+}
+
+// operandRelation compiles the relation used by a set operation.
+func (r *relation) operandRelation(arg syntax.Expr) (*relation, error) {
+	stages := pipelineStages(arg)
+	if ident, ok := arg.(syntax.Ident); ok {
+		stages = []syntax.Expr{syntax.Call{Position: ident.Position, Name: "from", Args: []syntax.Expr{ident}}}
+	}
+	return r.c.compilePipeline(&syntax.Pipeline{Position: arg.Pos(), Stages: stages})
+}
+
+// operand finishes the query of the relation for use within a set operation.
+// The order of the rows is not kept by the set operation, so is only used
+// when limiting the rows. Without nested set operations, ordered or limited
+// queries are used as a subquery instead.
+func (r *relation) operand() *selectQuery {
+	if r.stage == stageCompound || r.emulatesFullJoin() ||
+		(r.stage >= stageDistinct && !r.c.target.Supports(CapabilityNestedSetOperations)) {
+		r.split()
+	}
+
+	r.sort = nil
+	query := r.finish(true)
+
+	columns := make([]*column, 0, len(r.columns))
+	for _, col := range r.columns {
+		if !col.hidden {
+			columns = append(columns, col)
+		}
+	}
+	r.columns = columns
+	return query
+}
+
+// outputColumns returns the columns output by the relation, and false when
+// they are not known as all columns of a table are selected.
+func (r *relation) outputColumns() ([]*column, bool) {
+	var columns []*column
+	for _, col := range r.columns {
+		if col.isStar() {
+			return nil, false
+		}
+		if !col.hidden {
+			columns = append(columns, col)
+		}
+	}
+	return columns, true
+}
+
+// checkOperands ensures the relations of a set operation have the same number
+// of columns with compatible types.
+func (r *relation) checkOperands(call syntax.Call, right *relation) error {
+	leftColumns, leftKnown := r.outputColumns()
+	rightColumns, rightKnown := right.outputColumns()
+	if !leftKnown || !rightKnown {
+		return nil
+	}
+
+	if len(leftColumns) != len(rightColumns) {
+		return errorf(call.Args[0].Pos(), "'%s' expects a relation of %d columns but found %d", call.Name, len(leftColumns), len(rightColumns))
+	}
+	for i, col := range leftColumns {
+		left, right := typeOf(col.expr), typeOf(rightColumns[i].expr)
+		if !compatibleTypes(left, right) {
+			return errorf(call.Args[0].Pos(), "'%s' expects column %d to be %s but found %s", call.Name, i+1, left, right)
+		}
+	}
+	return nil
+}
+
+// compatibleTypes returns true if values of both types may be used together.
+// Unknown types are assumed to be compatible.
+func compatibleTypes(left, right syntax.Type) bool {
+	if left == right || left == syntax.TypeUnknown || right == syntax.TypeUnknown {
+		return true
+	}
+	numeric := func(typ syntax.Type) bool {
+		return typ == syntax.TypeInteger || typ == syntax.TypeFloat || typ == syntax.TypeScalar
+	}
+	return numeric(left) && numeric(right)
+}
+
+// existsOperation emulates "INTERSECT" or "EXCEPT" by filtering the distinct
+// rows of the relation on whether a matching row exists within the other. The
+// columns of both relations must be known to match the rows.
+func (r *relation) existsOperation(call syntax.Call, op string, right *relation) error {
+	rightColumns, rightKnown := right.outputColumns()
+	if _, leftKnown := r.outputColumns(); !leftKnown || !rightKnown {
+		return r.c.unsupported(call.Pos(), "'"+call.Name+"' of relations with unknown columns")
+	}
+
+	r.split()
+	leftColumns, _ := r.outputColumns()
+	leftAlias := r.query.from.alias
+
+	right.sort = nil
+	right.columns = rightColumns
+	rightAlias := r.c.nextTableName()
+	sub := newSelectQuery(&tableRef{alias: rightAlias, subquery: right.finish(false)})
+	sub.columns = []selectItem{{expr: literalInt(1)}}
+
+	for i, col := range leftColumns {
+		sub.where = and(sub.where, r.c.nullSafeEqual(
+			columnRef{leftAlias, col.name},
+			columnRef{rightAlias, rightColumns[i].name},
+		))
+	}
+
+	r.query.where = existsExpr{not: op == "EXCEPT", query: sub}
+	r.query.distinct = true
+	r.stage = stageDistinct
+	return nil
+}
+
+// nullSafeEqual compares two values, where nulls are equal to each other.
+func (c *compiler) nullSafeEqual(left, right sqlExpr) sqlExpr {
+	if template, ok := c.target.Function("<=>", ""); ok {
+		return funcExpr{template: template, args: []sqlExpr{left, right}, typ: syntax.TypeBoolean}
+	}
+	return binaryExpr{"IS NOT DISTINCT FROM", left, right}
+}
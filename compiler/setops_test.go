@@ -0,0 +1,152 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileSetOperations(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "append",
+			source: `
+from a
+append b`,
+			expected: `
+SELECT *
+FROM a
+UNION ALL
+SELECT *
+FROM b`,
+		},
+		{
+			name: "union then filter",
+			source: `
+from a
+select [x, y]
+union (from b | select [x, y])
+filter x > 1`,
+			expected: `
+SELECT x, y
+FROM (
+  SELECT x, y
+  FROM a
+  UNION
+  SELECT x, y
+  FROM b
+) AS table_0
+WHERE x > 1`,
+		},
+		{
+			name: "chained operators",
+			source: `
+from a
+select [x, y]
+append (from b | select [x, y = 1])
+append c
+intersect d`,
+			expected: `
+SELECT x, y
+FROM (
+  SELECT x, y
+  FROM a
+  UNION ALL
+  SELECT x, 1 AS y
+  FROM b
+  UNION ALL
+  SELECT *
+  FROM c
+) AS table_0
+INTERSECT
+SELECT *
+FROM d`,
+		},
+		{
+			name: "parenthesised ordered operands",
+			source: `
+from a
+sort x
+take 3
+remove (from b | sort y | take 2)`,
+			expected: `
+(
+  SELECT *
+  FROM a
+  ORDER BY x
+  LIMIT 3
+)
+EXCEPT
+(
+  SELECT *
+  FROM b
+  ORDER BY y
+  LIMIT 2
+)`,
+		},
+		{
+			name: "sqlite ordered operands",
+			source: `
+prql dialect:sqlite
+from a
+sort x
+take 3
+append (from b | sort y | take 2)`,
+			expected: `
+SELECT *
+FROM (
+  SELECT *
+  FROM a
+  ORDER BY x
+  LIMIT 3
+) AS table_0
+UNION ALL
+SELECT *
+FROM (
+  SELECT *
+  FROM b
+  ORDER BY y
+  LIMIT 2
+) AS table_1`,
+		},
+		{
+			name: "bigquery distinct operators",
+			source: `
+prql dialect:bigquery
+from a
+union b`,
+			expected: `
+SELECT *
+FROM a
+UNION DISTINCT
+SELECT *
+FROM b`,
+		},
+		{
+			name: "mysql except",
+			source: `
+prql dialect:mysql
+from a
+select [x, y]
+remove (from b | select [p, q])`,
+			expected: `
+SELECT DISTINCT x, y
+FROM (
+  SELECT x, y
+  FROM a
+) AS table_0
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM (
+    SELECT p, q
+    FROM b
+  ) AS table_1
+  WHERE table_0.x <=> table_1.p AND table_0.y <=> table_1.q
+)`,
+		},
+	})
+}
+
+func TestCompileSetOperationErrors(t *testing.T) {
+	compileError(t, "from a\nselect [x, y]\nappend (from b | select [p])", "3:8: 'append' expects a relation of 2 columns but found 1")
+	compileError(t, "from a\nselect [x, y = \"s\"]\nunion (from b | select [p, q = 1])", "3:7: 'union' expects column 2 to be string but found integer")
+	compileError(t, "prql dialect:mysql\nfrom a\nintersect b", "3:1: 'intersect' of relations with unknown columns is not supported by dialect mysql")
+	compileError(t, "from a\nappend", "2:1: 'append' expects 1 argument but received 0")
+	compileError(t, "from a\nremove", "2:1: 'remove' expects 1 argument but received 0")
+}
@@ -0,0 +1,61 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+)
+
+func TestCompileIdentifierStyles(t *testing.T) {
+	source := `prql dialect:snowflake
+from Orders
+derive [OrderTotal = total, order = 1, ` + "`my col`" + ` = 2]
+filter OrderTotal > 1`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "default",
+			source: source,
+			expected: `
+SELECT "Orders".*, total AS "OrderTotal", 1 AS "order", 2 AS "my col"
+FROM "Orders"
+WHERE total > 1`,
+		},
+	})
+
+	runCompileTestsWith(t, []compileTest{
+		{
+			name:   "quoted",
+			source: source,
+			expected: `
+SELECT "Orders".*, "total" AS "OrderTotal", 1 AS "order", 2 AS "my col"
+FROM "Orders"
+WHERE "total" > 1`,
+		},
+	}, compiler.Options{Identifiers: compiler.IdentifiersQuoted})
+
+	runCompileTestsWith(t, []compileTest{
+		{
+			name:   "bare",
+			source: source,
+			expected: `
+SELECT Orders.*, total AS OrderTotal, 1 AS "ORDER", 2 AS "my col"
+FROM Orders
+WHERE total > 1`,
+		},
+		{
+			name: "bare within postgres",
+			source: `prql dialect:postgres
+from Orders
+derive [order = 1]`,
+			expected: `
+SELECT Orders.*, 1 AS "order"
+FROM Orders`,
+		},
+	}, compiler.Options{Identifiers: compiler.IdentifiersBare})
+
+	var style compiler.IdentifierStyle
+	if err := style.UnmarshalText([]byte("bare")); err != nil || style != compiler.IdentifiersBare {
+		t.Errorf("expected IdentifiersBare, received %s (%v)", style, err)
+	}
+}
@@ -0,0 +1,126 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// NullsOrder is a byte enum declaring where null values are placed when
+// sorting rows.
+type NullsOrder byte
+
+const (
+	// NullsDefault leaves the placement of nulls to the database.
+	NullsDefault NullsOrder = iota
+
+	// NullsFirst places nulls before any other values.
+	NullsFirst
+
+	// NullsLast places nulls after any other values.
+	NullsLast
+)
+
+// holds NullsOrder -> string mapping
+var nullsOrderStringMap = map[NullsOrder]string{
+	NullsDefault: "default",
+	NullsFirst:   "first",
+	NullsLast:    "last",
+}
+
+// String returns the string representation of the NullsOrder enum. If invalid,
+// defaults to returning "default".
+func (n NullsOrder) String() string {
+	if str, ok := nullsOrderStringMap[n]; ok {
+		return str
+	}
+	return "default"
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Accepts
+// "default", "first", or "last".
+func (n *NullsOrder) UnmarshalText(text []byte) error {
+	str := string(text)
+	for order, name := range nullsOrderStringMap {
+		if name == str {
+			*n = order
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid NullsOrder '%s'", str)
+}
+
+// sortNulls returns the placement of nulls for a sort transform, which is
+// either given by the "nulls" named parameter, or the compile options.
+func (r *relation) sortNulls(call syntax.Call) (NullsOrder, error) {
+	arg, ok := call.Named["nulls"]
+	if !ok {
+		return r.c.opts.Nulls, nil
+	}
+
+	var nulls NullsOrder
+	ident, ok := arg.(syntax.Ident)
+	if !ok || nulls.UnmarshalText([]byte(ident.Name)) != nil {
+		return nulls, errorf(arg.Pos(), "'nulls' expects first, last, or default but found %s", arg.String())
+	}
+	return nulls, nil
+}
+
+// translateSort converts the expressions of a sort transform. A "-" prefix
+// sorts in descending order, and a "+" prefix in ascending order.
+func (r *relation) translateSort(call *syntax.Call) ([]sortItem, error) {
+	if call == nil {
+		return nil, nil
+	}
+
+	arg, err := singleArg(*call)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range call.Named {
+		if name != "nulls" {
+			return nil, errorf(value.Pos(), "'sort' has no parameter named '%s'", name)
+		}
+	}
+
+	nulls, err := r.sortNulls(*call)
+	if err != nil {
+		return nil, err
+	}
+
+	items := listItems(arg)
+	sort := make([]sortItem, 0, len(items))
+	for _, item := range items {
+		desc := false
+		if unary, ok := item.(syntax.Unary); ok && (unary.Op == "-" || unary.Op == "+") {
+			desc = unary.Op == "-"
+			item = unary.Operand
+		}
+
+		expr, err := r.translate(item)
+		if err != nil {
+			return nil, err
+		}
+		sort = append(sort, sortItem{expr: expr, desc: desc, nulls: nulls})
+	}
+	return sort, nil
+}
+
+// sortBy orders the rows. The order is kept by the relation, and carried
+// through the later transforms until the rows are aggregated.
+func (r *relation) sortBy(call syntax.Call) error {
+	sort, err := r.translateSort(&call)
+	if err != nil {
+		return err
+	}
+
+	// Distinct rows may be ordered within the same query by their columns
+	if r.stage >= stageDistinct && !(r.stage == stageDistinct && r.query.distinct && r.selected(sort)) {
+		r.split()
+		if sort, err = r.translateSort(&call); err != nil {
+			return err
+		}
+	}
+	r.sort = sort
+	return nil
+}
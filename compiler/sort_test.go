@@ -0,0 +1,129 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+)
+
+func TestCompileSort(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "prefixes and expressions",
+			source: `
+from invoices
+sort [-amount, +date, (total - discount)]`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY amount DESC, date, total - discount`,
+		},
+		{
+			name: "named nulls",
+			source: `
+from invoices
+sort nulls:last -amount`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY amount DESC NULLS LAST`,
+		},
+		{
+			name: "nulls emulated",
+			source: `
+prql dialect:mssql
+from invoices
+sort nulls:first [-amount]`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY CASE WHEN amount IS NULL THEN 0 ELSE 1 END, amount DESC`,
+		},
+		{
+			name: "carried through a subquery",
+			source: `
+from invoices
+sort (amount * 2)
+take 10
+filter amount > 0`,
+			expected: `
+SELECT *
+FROM (
+  SELECT invoices.*, amount * 2 AS _expr_0
+  FROM invoices
+  ORDER BY amount * 2
+  LIMIT 10
+) AS table_0
+WHERE amount > 0
+ORDER BY _expr_0`,
+		},
+		{
+			name: "inherited by group",
+			source: `
+from invoices
+select [customer, date, total]
+sort -date
+group customer (take 1)`,
+			expected: `
+SELECT customer, date, total
+FROM (
+  SELECT customer, date, total, ROW_NUMBER() OVER (PARTITION BY customer ORDER BY date DESC) AS _expr_0
+  FROM invoices
+) AS table_0
+WHERE _expr_0 <= 1
+ORDER BY date DESC`,
+		},
+		{
+			name: "inherited by window functions",
+			source: `
+from invoices
+sort date
+derive n = row_number`,
+			expected: `
+SELECT invoices.*, ROW_NUMBER() OVER (ORDER BY date) AS n
+FROM invoices
+ORDER BY date`,
+		},
+		{
+			name: "lost by aggregate",
+			source: `
+from invoices
+sort date
+aggregate [total = sum amount]`,
+			expected: `
+SELECT SUM(amount) AS total
+FROM invoices`,
+		},
+	})
+}
+
+func TestCompileSortNullsOption(t *testing.T) {
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "from options",
+			source: `
+prql dialect:mysql
+from invoices
+sort amount`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY CASE WHEN amount IS NULL THEN 1 ELSE 0 END, amount`,
+		},
+		{
+			name: "overridden by sort",
+			source: `
+from invoices
+sort nulls:first amount`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY amount NULLS FIRST`,
+		},
+	}, compiler.Options{Nulls: compiler.NullsLast})
+}
+
+func TestCompileSortErrors(t *testing.T) {
+	compileError(t, "from e\nsort nulls:middle a", "'nulls' expects first, last, or default")
+	compileError(t, "from e\nsort order:desc a", "'sort' has no parameter named 'order'")
+}
@@ -0,0 +1,131 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+)
+
+func TestCompileSplitSubqueries(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "filter after take",
+			source: `
+from t
+sort a
+take 10
+filter b > 1`,
+			expected: `
+SELECT *
+FROM (
+  SELECT *
+  FROM t
+  ORDER BY a
+  LIMIT 10
+) AS table_0
+WHERE b > 1
+ORDER BY a`,
+		},
+		{
+			name: "window after aggregate",
+			source: `
+from t
+group c (aggregate [total = sum x])
+derive [share = total / (sum total)]
+sort -total`,
+			expected: `
+SELECT c, total, total / SUM(total) OVER () AS share
+FROM (
+  SELECT c, SUM(x) AS total
+  FROM t
+  GROUP BY c
+) AS table_0
+ORDER BY total DESC`,
+		},
+		{
+			name: "derive and sort on aggregated alias",
+			source: `
+from t
+group c (aggregate [total = sum x])
+derive [double = total * 2]
+sort -total`,
+			expected: `
+SELECT c, SUM(x) AS total, SUM(x) * 2 AS double
+FROM t
+GROUP BY c
+ORDER BY SUM(x) DESC`,
+		},
+	})
+}
+
+func TestCompileSplitCommonTables(t *testing.T) {
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "filter after take",
+			source: `
+from t
+sort a
+take 10
+filter b > 1`,
+			expected: `
+WITH table_0 AS (
+  SELECT *
+  FROM t
+  ORDER BY a
+  LIMIT 10
+)
+SELECT *
+FROM table_0
+WHERE b > 1
+ORDER BY a`,
+		},
+		{
+			name: "join after group",
+			source: `
+from t
+group c (aggregate [total = sum x])
+sort -total
+take 5
+join u [==c]`,
+			expected: `
+WITH table_0 AS (
+  SELECT c, SUM(x) AS total
+  FROM t
+  GROUP BY c
+  ORDER BY SUM(x) DESC
+  LIMIT 5
+)
+SELECT table_0.c, table_0.total, u.*
+FROM table_0
+JOIN u ON table_0.c = u.c
+ORDER BY table_0.total DESC`,
+		},
+		{
+			name: "chained with join pipeline",
+			source: `
+from t
+join (from u | take 3) [==id]
+take 1
+filter x > 1`,
+			expected: `
+WITH table_0 AS (
+  SELECT *
+  FROM u
+  LIMIT 3
+),
+table_1 AS (
+  SELECT t.*, table_0.*
+  FROM t
+  JOIN table_0 ON t.id = table_0.id
+  LIMIT 1
+)
+SELECT *
+FROM table_1
+WHERE x > 1`,
+		},
+	}, compiler.Options{Splits: compiler.SplitCommonTables})
+
+	// The recursive table may not be read within a subquery, or a common table
+	compileErrorWith(t, "from t\nloop (take 1 | filter x > 1)", compiler.Options{Splits: compiler.SplitCommonTables},
+		"2:16: a subquery for 'filter' in 'loop' is not supported by dialect generic")
+}
@@ -0,0 +1,441 @@
+package compiler
+
+import (
+	"strconv"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// sqlExpr is any node of a SQL expression. Expressions are built while the
+// pipeline is compiled, and rendered into text by the generator once the
+// query structure is complete.
+type sqlExpr interface{}
+
+// columnRef is a reference to a column, optionally qualified by a relation.
+type columnRef struct {
+	relation string
+	name     string
+}
+
+// starExpr selects all the columns of a relation, or every relation in scope
+// when the relation is empty.
+type starExpr struct {
+	relation string
+
+	// except holds the names of columns not included, "* EXCEPT (...)"
+	except []string
+}
+
+// literalExpr is a constant value, as it appeared in the PRQL source.
+type literalExpr struct {
+	typ   syntax.Type
+	value string
+}
+
+// isNull returns true if this literal is a SQL NULL.
+func (l literalExpr) isNull() bool {
+	return l.typ == syntax.TypeUnknown && l.value == "null"
+}
+
+// intervalExpr is a duration such as "7days", with the unit as written in
+// PRQL.
+type intervalExpr struct {
+	value string
+	unit  string
+}
+
+// binaryExpr is an operation between two expressions. The operator is the
+// SQL operator, such as "=" or "AND".
+type binaryExpr struct {
+	op    string
+	left  sqlExpr
+	right sqlExpr
+}
+
+// unaryExpr is an operation on a single expression, such as "-" or "NOT".
+type unaryExpr struct {
+	op      string
+	operand sqlExpr
+}
+
+// funcExpr is a call to a SQL function "NAME(args...)", or the SQL of the
+// template with the arguments in place of "{0}", "{1}" and so on.
+type funcExpr struct {
+	name     string
+	template string
+	args     []sqlExpr
+	typ      syntax.Type
+
+	// star renders the arguments as "*", such as "COUNT(*)"
+	star bool
+
+	// aggregate marks the function as aggregating many rows into one
+	aggregate bool
+
+	// window marks the function as only valid within a window "OVER" clause
+	window bool
+
+	// framed marks a window function which accepts a frame, such as
+	// "FIRST_VALUE". Aggregates always accept a frame.
+	framed bool
+
+	// filter is the condition of the rows an aggregate function includes, or
+	// nil to include every row
+	filter sqlExpr
+}
+
+// castExpr converts the value into another type, "CAST(value AS type)".
+type castExpr struct {
+	value sqlExpr
+	typ   syntax.Type
+}
+
+// likeExpr matches the text value against a LIKE pattern, where any special
+// characters to match literally are escaped with a backslash.
+type likeExpr struct {
+	value   sqlExpr
+	pattern string
+
+	// insensitive ignores the case of the letters, where the pattern is
+	// already lower case
+	insensitive bool
+}
+
+// windowExpr applies a function over a window of rows.
+type windowExpr struct {
+	fn        sqlExpr
+	partition []sqlExpr
+	order     []sortItem
+
+	// frame limits the rows of the window, or nil to use the default frame
+	frame *windowFrame
+}
+
+// windowFrame is the "ROWS" or "RANGE" frame of a window, between the start
+// and end bounds.
+type windowFrame struct {
+	kind  string
+	start frameBound
+	end   frameBound
+}
+
+// frameBound is one end of a window frame. An offset of nil is either the
+// current row, or unbounded when that is set. Negative offsets precede the
+// current row.
+type frameBound struct {
+	unbounded bool
+	offset    sqlExpr
+	preceding bool
+}
+
+// caseExpr is a "CASE WHEN ... THEN ... ELSE ... END" expression. The else
+// value is nil when there is no ELSE.
+type caseExpr struct {
+	whens []caseWhen
+	els   sqlExpr
+	typ   syntax.Type
+}
+
+// caseWhen is a single "WHEN cond THEN value" of a caseExpr.
+type caseWhen struct {
+	cond  sqlExpr
+	value sqlExpr
+}
+
+// existsExpr tests whether a subquery returns any rows.
+type existsExpr struct {
+	not   bool
+	query *selectQuery
+}
+
+// rawPart is a piece of a rawExpr, either text or an expression.
+type rawPart struct {
+	text string
+	expr sqlExpr
+}
+
+// rawExpr is SQL given directly by an s-string, with any interpolated
+// expressions.
+type rawExpr struct {
+	parts []rawPart
+}
+
+// sortItem is an expression used for ordering rows.
+type sortItem struct {
+	expr  sqlExpr
+	desc  bool
+	nulls NullsOrder
+}
+
+// selectItem is a single column of the SELECT clause.
+type selectItem struct {
+	expr  sqlExpr
+	alias string
+}
+
+// tableRef is a relation within a FROM or JOIN clause. Either the name of a
+// table is used, the subquery when it is not nil, or the series.
+type tableRef struct {
+	name     string
+	alias    string
+	subquery *selectQuery
+	series   *seriesRef
+}
+
+// seriesRef is a relation of the integers between start and end inclusive,
+// within a single column.
+type seriesRef struct {
+	start  sqlExpr
+	end    sqlExpr
+	column string
+}
+
+// selectQuery is a single SQL SELECT statement.
+type selectQuery struct {
+	distinct   bool
+	distinctOn []sqlExpr
+	columns    []selectItem
+	from       *tableRef
+	joins      []*joinClause
+	where      sqlExpr
+	groupBy    []sqlExpr
+	having     sqlExpr
+	qualify    sqlExpr
+	orderBy    []sortItem
+
+	// limit is negative when no limit is applied
+	limit  int64
+	offset int64
+
+	// limitBy keeps the first rows of each group, "LIMIT n BY ..."
+	limitBy *limitBy
+
+	// compound holds the queries combined with this one by set operations
+	compound []setOperation
+}
+
+// limitBy keeps a number of rows of each group of rows with the same values
+// of the expressions, after skipping the offset.
+type limitBy struct {
+	limit  int64
+	offset int64
+	by     []sqlExpr
+}
+
+// commonTable is a named query of a WITH clause. A recursive table
+// references itself within it's query.
+type commonTable struct {
+	name      string
+	query     *selectQuery
+	recursive bool
+}
+
+// setOperation combines the rows of a query with the query before it, using
+// a SQL set operator such as "UNION ALL".
+type setOperation struct {
+	op    string
+	query *selectQuery
+}
+
+// ordered returns true if the query orders or limits it's rows, which must be
+// done within parenthesis or a subquery when used by a set operation.
+func (q *selectQuery) ordered() bool {
+	return len(q.orderBy) > 0 || q.limit >= 0 || q.offset > 0 || q.distinctOn != nil || q.limitBy != nil
+}
+
+// newSelectQuery creates an empty selectQuery without any limit.
+func newSelectQuery(from *tableRef) *selectQuery {
+	return &selectQuery{
+		from:  from,
+		limit: -1,
+	}
+}
+
+// and joins two conditions, where either may be nil.
+func and(left, right sqlExpr) sqlExpr {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	return binaryExpr{"AND", left, right}
+}
+
+// walkExpr calls visit for the expression and each of it's children, depth
+// first. When visit returns false the children of that node are skipped.
+func walkExpr(expr sqlExpr, visit func(sqlExpr) bool) {
+	if expr == nil || !visit(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case binaryExpr:
+		walkExpr(e.left, visit)
+		walkExpr(e.right, visit)
+	case unaryExpr:
+		walkExpr(e.operand, visit)
+	case funcExpr:
+		for _, arg := range e.args {
+			walkExpr(arg, visit)
+		}
+		walkExpr(e.filter, visit)
+	case windowExpr:
+		walkExpr(e.fn, visit)
+		for _, part := range e.partition {
+			walkExpr(part, visit)
+		}
+		for _, item := range e.order {
+			walkExpr(item.expr, visit)
+		}
+	case likeExpr:
+		walkExpr(e.value, visit)
+	case castExpr:
+		walkExpr(e.value, visit)
+	case caseExpr:
+		for _, when := range e.whens {
+			walkExpr(when.cond, visit)
+			walkExpr(when.value, visit)
+		}
+		walkExpr(e.els, visit)
+	case rawExpr:
+		for _, part := range e.parts {
+			walkExpr(part.expr, visit)
+		}
+	}
+}
+
+// containsAggregate returns true if the expression aggregates rows outside of
+// any window.
+func containsAggregate(expr sqlExpr) bool {
+	found := false
+	walkExpr(expr, func(e sqlExpr) bool {
+		switch fn := e.(type) {
+		case windowExpr:
+			return false
+		case funcExpr:
+			if fn.aggregate {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// containsWindow returns true if the expression holds a window function.
+func containsWindow(expr sqlExpr) bool {
+	found := false
+	walkExpr(expr, func(e sqlExpr) bool {
+		if _, ok := e.(windowExpr); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// typeOf returns the inferred type of the expression, which is TypeUnknown
+// when it cannot be known, such as for a column of a table.
+func typeOf(expr sqlExpr) syntax.Type {
+	switch e := expr.(type) {
+	case literalExpr:
+		return e.typ
+	case funcExpr:
+		return e.typ
+	case windowExpr:
+		return typeOf(e.fn)
+	case existsExpr, likeExpr:
+		return syntax.TypeBoolean
+	case caseExpr:
+		return e.typ
+	case castExpr:
+		return e.typ
+	case unaryExpr:
+		if e.op == "NOT" {
+			return syntax.TypeBoolean
+		}
+		return typeOf(e.operand)
+	case binaryExpr:
+		switch e.op {
+		case "AND", "OR", "=", "<>", "<", "<=", ">", ">=", "IS", "IS NOT", "<=>", "IS NOT DISTINCT FROM", "~", "REGEXP", "RLIKE", "LIKE_REGEX":
+			return syntax.TypeBoolean
+		case "||":
+			return syntax.TypeString
+		}
+		left, right := typeOf(e.left), typeOf(e.right)
+		if left == syntax.TypeFloat || right == syntax.TypeFloat || e.op == "/" {
+			return syntax.TypeFloat
+		}
+		if left == right {
+			return left
+		}
+	}
+	return syntax.TypeUnknown
+}
+
+// mapExpr rebuilds the expression, replacing each node with the result of fn.
+// Nodes are visited top down, and when fn returns true for "done" the node
+// returned replaces the original without visiting it's children.
+func mapExpr(expr sqlExpr, fn func(sqlExpr) (sqlExpr, bool)) sqlExpr {
+	if expr == nil {
+		return nil
+	}
+	if replaced, done := fn(expr); done {
+		return replaced
+	}
+
+	switch e := expr.(type) {
+	case binaryExpr:
+		return binaryExpr{e.op, mapExpr(e.left, fn), mapExpr(e.right, fn)}
+	case unaryExpr:
+		return unaryExpr{e.op, mapExpr(e.operand, fn)}
+	case funcExpr:
+		args := make([]sqlExpr, len(e.args))
+		for i, arg := range e.args {
+			args[i] = mapExpr(arg, fn)
+		}
+		e.args = args
+		e.filter = mapExpr(e.filter, fn)
+		return e
+	case windowExpr:
+		e.fn = mapExpr(e.fn, fn)
+		partition := make([]sqlExpr, len(e.partition))
+		for i, part := range e.partition {
+			partition[i] = mapExpr(part, fn)
+		}
+		e.partition = partition
+		order := make([]sortItem, len(e.order))
+		for i, item := range e.order {
+			order[i] = sortItem{mapExpr(item.expr, fn), item.desc, item.nulls}
+		}
+		e.order = order
+		return e
+	case likeExpr:
+		e.value = mapExpr(e.value, fn)
+		return e
+	case castExpr:
+		e.value = mapExpr(e.value, fn)
+		return e
+	case caseExpr:
+		whens := make([]caseWhen, len(e.whens))
+		for i, when := range e.whens {
+			whens[i] = caseWhen{mapExpr(when.cond, fn), mapExpr(when.value, fn)}
+		}
+		e.whens = whens
+		e.els = mapExpr(e.els, fn)
+		return e
+	case rawExpr:
+		parts := make([]rawPart, len(e.parts))
+		for i, part := range e.parts {
+			parts[i] = rawPart{part.text, mapExpr(part.expr, fn)}
+		}
+		return rawExpr{parts}
+	}
+	return expr
+}
+
+// literalInt creates an integer literal.
+func literalInt(value int64) literalExpr {
+	return literalExpr{syntax.TypeInteger, strconv.FormatInt(value, 10)}
+}
@@ -0,0 +1,12 @@
+package compiler
+
+import "github.com/chris-pikul/go-prql/syntax"
+
+// sqliteDateFunctions holds the function formatting each temporal type. SQLite
+// has no types for dates, so keeps them as text in the format of these
+// functions, to which casts into a temporal type are compiled.
+var sqliteDateFunctions = map[syntax.Type]string{
+	syntax.TypeDate:      "DATE",
+	syntax.TypeTime:      "TIME",
+	syntax.TypeTimestamp: "DATETIME",
+}
@@ -0,0 +1,87 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+)
+
+func TestCompileSQLiteJoins(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "full join",
+			source: `prql dialect:sqlite
+from a
+join side:full b [==id]`,
+			expected: `
+SELECT a.*, b.*
+FROM a
+FULL JOIN b ON a.id = b.id`,
+		},
+	})
+
+	runCompileTestsWith(t, []compileTest{
+		{
+			name: "right join swaps the relations",
+			source: `prql dialect:sqlite
+from a
+join side:right b [==id]
+join c [a.cid == c.cid]`,
+			expected: `
+SELECT a.*, b.*, c.*
+FROM b
+LEFT JOIN a ON a.id = b.id
+JOIN c ON a.cid = c.cid`,
+		},
+		{
+			name: "right join after another join",
+			source: `prql dialect:sqlite
+from a
+join b [==id]
+join side:right c [==id]`,
+			expected: `
+SELECT table_0.*, c.*
+FROM c
+LEFT JOIN (
+  SELECT a.*, b.*
+  FROM a
+  JOIN b ON a.id = b.id
+) AS table_0 ON table_0.id = c.id`,
+		},
+		{
+			name: "full join emulated",
+			source: `prql dialect:sqlite
+from a
+join side:full b [==id]`,
+			expected: `
+SELECT a.*, b.*
+FROM a
+LEFT JOIN b ON a.id = b.id
+UNION ALL
+SELECT a.*, b.*
+FROM b
+LEFT JOIN a ON a.id = b.id
+WHERE (a.id = b.id) IS NOT 1`,
+		},
+		{
+			name: "nulls emulated",
+			source: `prql dialect:sqlite
+from a
+sort nulls:last -b`,
+			expected: `
+SELECT *
+FROM a
+ORDER BY CASE WHEN b IS NULL THEN 1 ELSE 0 END, b DESC`,
+		},
+		{
+			name: "aggregate filter emulated",
+			source: `prql dialect:sqlite
+from a
+group x (aggregate [n = count filter:(y > 1)])`,
+			expected: `
+SELECT x, COUNT(CASE WHEN y > 1 THEN 1 END) AS n
+FROM a
+GROUP BY x`,
+		},
+	}, compiler.Options{SQLite: compiler.SQLite325})
+}
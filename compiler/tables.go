@@ -0,0 +1,59 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// declaredTable is a named relation declared by the query. It is compiled
+// into a common table the first time it is referenced.
+type declaredTable struct {
+	decl *syntax.Table
+
+	// rel is the compiled relation, or nil until it is referenced
+	rel       *relation
+	compiling bool
+}
+
+// declare registers the named relations declared by the query.
+func (c *compiler) declare(tables []*syntax.Table) error {
+	c.declared = make(map[string]*declaredTable, len(tables))
+	for _, table := range tables {
+		if _, exists := c.declared[table.Name]; exists {
+			return errorf(table.Pos(), "table '%s' is already declared", table.Name)
+		}
+		c.declared[table.Name] = &declaredTable{decl: table}
+	}
+	return nil
+}
+
+// useTable returns the relation of the declared table referenced by name,
+// compiling it into a common table when first used. Tables which are never
+// referenced are left out of the query. Returns nil if no table of the name
+// was declared.
+func (c *compiler) useTable(ident syntax.Ident) (*relation, error) {
+	table, ok := c.declared[ident.Name]
+	if !ok {
+		return nil, nil
+	}
+	if table.rel != nil {
+		return table.rel, nil
+	}
+	if table.compiling {
+		return nil, errorf(ident.Pos(), "table '%s' references itself", ident.Name)
+	}
+
+	if !c.target.Supports(CapabilityCommonTables) {
+		return nil, c.unsupported(ident.Pos(), "declared table '"+ident.Name+"'")
+	}
+
+	table.compiling = true
+	rel, err := c.compilePipeline(table.decl.Pipeline)
+	table.compiling = false
+	if err != nil {
+		return nil, err
+	}
+
+	c.tables = append(c.tables, &commonTable{name: ident.Name, query: rel.finishTable()})
+	table.rel = rel
+	return rel, nil
+}
@@ -0,0 +1,64 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileTables(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "referenced by joins and set operations",
+			source: `
+let top_customers = (from customers | sort -total | take 10 | select [id, name])
+let unused = (from x)
+table recent = (from orders | filter date > @2024-01-01)
+
+from recent
+join top_customers [recent.customer_id == top_customers.id]
+append (from top_customers | select [id, name] | join recent [==id])`,
+			expected: `
+WITH recent AS (
+  SELECT *
+  FROM orders
+  WHERE date > DATE '2024-01-01'
+),
+top_customers AS (
+  SELECT id, name
+  FROM customers
+  ORDER BY total DESC
+  LIMIT 10
+)
+SELECT recent.*, top_customers.*
+FROM recent
+JOIN top_customers ON recent.customer_id = top_customers.id
+UNION ALL
+SELECT top_customers.id, top_customers.name, recent.*
+FROM top_customers
+JOIN recent ON top_customers.id = recent.id`,
+		},
+		{
+			name: "referencing another table",
+			source: `
+let a = (from b | select [x, y = x * 2])
+let c = (from a | filter y > 1)
+from c
+select [y]`,
+			expected: `
+WITH a AS (
+  SELECT x, x * 2 AS y
+  FROM b
+),
+c AS (
+  SELECT x, y
+  FROM a
+  WHERE y > 1
+)
+SELECT y
+FROM c`,
+		},
+	})
+}
+
+func TestCompileTableErrors(t *testing.T) {
+	compileError(t, "let a = (from a | take 1)\nfrom a", "1:15: table 'a' references itself")
+	compileError(t, "let a = from b\nlet a = from c\nfrom a", "2:1: table 'a' is already declared")
+	compileError(t, "let a = (from b | select [x])\nfrom a\nselect [y]", "3:9: unknown name 'y'")
+}
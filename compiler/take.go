@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"strconv"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// take picks a range of rows by their position, in the current order of the
+// relation. A take within rows which have already been taken picks from those
+// rows, otherwise a filter or window afterwards requires a subquery.
+func (r *relation) take(call syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := takeRange(arg)
+	if err != nil {
+		return err
+	}
+
+	// The distinct rows may be limited within the same query, as long as their
+	// order only uses selected columns
+	if r.stage == stageDistinct && (!r.query.distinct || !r.selected(r.sort)) {
+		r.split()
+	}
+
+	if r.stage == stageLimit {
+		// Take from within the rows already taken
+		offset, limit := r.query.offset, r.query.limit
+		start += offset
+		if end >= 0 {
+			end += offset
+		}
+		if limit >= 0 && (end < 0 || end > offset+limit) {
+			end = offset + limit
+		}
+	}
+
+	offset, limit := start-1, int64(-1)
+	if end >= 0 {
+		limit = end - start + 1
+		if limit < 0 {
+			limit = 0
+		}
+	}
+	if offset > 0 && !r.c.target.Supports(CapabilityOffset) {
+		return r.skipRows(call.Pos(), offset, limit)
+	}
+
+	r.query.offset = offset
+	r.query.limit = limit
+	r.query.orderBy = r.sort
+	r.stage = stageLimit
+	return nil
+}
+
+// skipRows takes the rows after the offset for the dialects without "OFFSET",
+// numbering the rows within a subquery, and then filtering on their number.
+func (r *relation) skipRows(pos syntax.Position, offset, limit int64) error {
+	if r.stage >= stageAggregate {
+		r.split()
+	}
+
+	rowNumber, err := r.c.callFunction(pos, "row_number", nil, nil)
+	if err != nil {
+		return err
+	}
+	name := r.c.nextExprName()
+	r.columns = append(r.columns, &column{name: name, expr: windowExpr{fn: rowNumber, order: r.sort}, hidden: true})
+	r.split()
+
+	r.query.where = binaryExpr{">", columnRef{name: name}, literalInt(offset)}
+	r.query.limit = limit
+	r.query.orderBy = r.sort
+	r.stage = stageLimit
+	return nil
+}
+
+// takeRange returns the 1-based, inclusive range of rows for a take transform.
+// Either a number of rows "take 10", or a range "take 5..10" is accepted. The
+// end is negative when the range is left open, such as "take 5..".
+func takeRange(arg syntax.Expr) (int64, int64, error) {
+	rng, ok := arg.(syntax.Range)
+	if !ok {
+		count, err := takeBound(arg)
+		return 1, count, err
+	}
+
+	var start, end int64 = 1, -1
+	var err error
+	if rng.Start != nil {
+		if start, err = takeBound(rng.Start); err != nil {
+			return 0, 0, err
+		}
+		if start < 1 {
+			return 0, 0, errorf(rng.Start.Pos(), "'take' range must start at 1 or more but found %d", start)
+		}
+	}
+	if rng.End != nil {
+		if end, err = takeBound(rng.End); err != nil {
+			return 0, 0, err
+		}
+		if end < start {
+			return 0, 0, errorf(rng.End.Pos(), "'take' range must not end before it starts")
+		}
+	}
+	return start, end, nil
+}
+
+// takeBound reads a number of rows, or a bound of a take range.
+func takeBound(arg syntax.Expr) (int64, error) {
+	lit, ok := arg.(syntax.Literal)
+	if !ok || lit.Type != syntax.TypeInteger {
+		return 0, errorf(arg.Pos(), "'take' expects a number of rows or a range but found %s", arg.String())
+	}
+
+	count, err := strconv.ParseInt(lit.Value, 10, 64)
+	if err != nil {
+		return 0, errorf(arg.Pos(), "invalid number of rows %s", lit.Value)
+	}
+	return count, nil
+}
@@ -0,0 +1,159 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileTake(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "count",
+			source: `
+from invoices
+take 20`,
+			expected: `
+SELECT *
+FROM invoices
+LIMIT 20`,
+		},
+		{
+			name: "range",
+			source: `
+from invoices
+sort date
+take 11..20`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY date
+LIMIT 10 OFFSET 10`,
+		},
+		{
+			name: "open range",
+			source: `
+from invoices
+take 5..`,
+			expected: `
+SELECT *
+FROM invoices
+OFFSET 4`,
+		},
+		{
+			name: "within taken rows",
+			source: `
+from invoices
+take 10..20
+take 3..5`,
+			expected: `
+SELECT *
+FROM invoices
+LIMIT 3 OFFSET 11`,
+		},
+		{
+			name: "filter requires subquery",
+			source: `
+from invoices
+take 10
+filter amount > 100`,
+			expected: `
+SELECT *
+FROM (
+  SELECT *
+  FROM invoices
+  LIMIT 10
+) AS table_0
+WHERE amount > 100`,
+		},
+		{
+			name: "range within group",
+			source: `
+from invoices
+select [customer, date, total]
+group customer (sort date | take 2..3)`,
+			expected: `
+SELECT customer, date, total
+FROM (
+  SELECT customer, date, total, ROW_NUMBER() OVER (PARTITION BY customer ORDER BY date) AS _expr_0
+  FROM invoices
+) AS table_0
+WHERE _expr_0 >= 2 AND _expr_0 <= 3`,
+		},
+	})
+}
+
+func TestCompileTakeDialects(t *testing.T) {
+	const source = `
+from invoices
+sort date
+take 11..20`
+
+	runCompileTests(t, []compileTest{
+		{
+			name:   "mysql",
+			source: "prql dialect:mysql\n" + source,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY date
+LIMIT 10, 10`,
+		},
+		{
+			name:   "mssql",
+			source: "prql dialect:mssql\n" + source,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY date
+OFFSET 10 ROWS
+FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name: "mssql top",
+			source: `
+prql dialect:mssql
+from invoices
+take 20`,
+			expected: `
+SELECT TOP (20) *
+FROM invoices`,
+		},
+		{
+			name: "mssql requires order",
+			source: `
+prql dialect:mssql
+from invoices
+take 5..`,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY (SELECT NULL)
+OFFSET 4 ROWS`,
+		},
+		{
+			name:   "ansi",
+			source: "prql dialect:ansi\n" + source,
+			expected: `
+SELECT *
+FROM invoices
+ORDER BY date
+OFFSET 10 ROWS
+FETCH FIRST 10 ROWS ONLY`,
+		},
+		{
+			name: "sqlite open range",
+			source: `
+prql dialect:sqlite
+from invoices
+take 5..`,
+			expected: `
+SELECT *
+FROM invoices
+LIMIT -1 OFFSET 4`,
+		},
+	})
+}
+
+func TestCompileTakeErrors(t *testing.T) {
+	compileError(t, "from e\ntake 0..5", "'take' range must start at 1 or more")
+	compileError(t, "from e\ntake 5..2", "'take' range must not end before it starts")
+	compileError(t, "from e\ntake x", "'take' expects a number of rows or a range")
+	compileError(t, "from e\ntake", "2:1: 'take' expects 1 argument but received 0")
+}
@@ -0,0 +1,2 @@
+SELECT orders.*, CAST(quantity AS INT64) AS units, CAST(price AS FLOAT64) AS unit_price, CAST(order_id AS STRING) AS reference, DATE_TRUNC(created, MONTH) AS month
+FROM orders
@@ -0,0 +1,3 @@
+SELECT customer_id, COUNTIF(total > 100) AS large, COUNTIF(status = 'returned') AS returned
+FROM orders
+GROUP BY customer_id
@@ -0,0 +1,3 @@
+SELECT e.* EXCEPT (ssn), d.* EXCEPT (budget)
+FROM employees AS e
+JOIN departments AS d ON e.dept_id = d.dept_id
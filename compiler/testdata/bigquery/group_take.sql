@@ -0,0 +1,4 @@
+SELECT *
+FROM employees
+WHERE TRUE
+QUALIFY ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) <= 2
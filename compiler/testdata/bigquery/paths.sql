@@ -0,0 +1,3 @@
+SELECT order_id, total, name
+FROM `my-project.sales.orders`
+JOIN `my-project.sales.customers` ON orders.customer_id = customers.customer_id
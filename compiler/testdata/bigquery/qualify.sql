@@ -0,0 +1,5 @@
+SELECT employees.*, RANK() OVER (ORDER BY salary DESC) AS salary_rank
+FROM employees
+WHERE TRUE
+QUALIFY RANK() OVER (ORDER BY salary DESC) <= 3
+ORDER BY salary DESC
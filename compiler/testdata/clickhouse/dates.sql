@@ -0,0 +1,3 @@
+SELECT orders.*, toStartOfMonth(created) AS month, toDayOfMonth(created) AS day, lengthUTF8(upperUTF8(reference)) AS code
+FROM orders
+WHERE created >= DATE '2024-01-01'
@@ -0,0 +1,3 @@
+SELECT customer_id, uniqExact(product_id) AS products, countIf(total > 100) AS large, countIf(status = 'returned') AS returned, avg(total) AS average
+FROM orders
+GROUP BY customer_id
@@ -0,0 +1,4 @@
+SELECT *
+FROM employees
+ORDER BY salary DESC
+LIMIT 3 BY dept
@@ -0,0 +1,8 @@
+SELECT *
+FROM (
+  SELECT *
+  FROM employees
+  ORDER BY salary DESC
+  LIMIT 3 OFFSET 1 BY dept, title
+) AS table_0
+WHERE salary > 1000
@@ -0,0 +1,3 @@
+SELECT orders.*, CONCAT(reference, ' (', status, ')') AS label, DATE_ADD(created, 14) AS due, DATE_SUB(created, 3) AS reminder, ADD_MONTHS(created, 12) AS renewal, TIMESTAMP '2024-01-01 09:00' + INTERVAL '1' MONTH AS review, DATEDIFF(shipped, created) AS days, CAST(DATEDIFF(shipped, created) / 7 AS INT) AS weeks, CAST(MONTHS_BETWEEN(shipped, created) AS INT) AS months, CAST((UNIX_TIMESTAMP(shipped) - UNIX_TIMESTAMP(created)) / 3600 AS INT) AS hours, DATE_FORMAT(created, 'dd/MM/yyyy\' at \'HH:mm') AS formatted, DATE_FORMAT(created, 'HH\' o\'\'clock\'') AS hour_text
+FROM orders
+WHERE opened_at < '12:30'
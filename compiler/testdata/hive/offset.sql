@@ -0,0 +1,8 @@
+SELECT *
+FROM (
+  SELECT orders.*, ROW_NUMBER() OVER (ORDER BY created DESC, id) AS _expr_0
+  FROM orders
+) AS table_0
+WHERE _expr_0 > 20
+ORDER BY created DESC, id
+LIMIT 10
@@ -0,0 +1,11 @@
+SELECT customer, total
+FROM (
+  SELECT customer, total, ROW_NUMBER() OVER (ORDER BY total DESC) AS _expr_0
+  FROM (
+    SELECT customer, SUM(amount) AS total
+    FROM orders
+    GROUP BY customer
+  ) AS table_0
+) AS table_1
+WHERE _expr_0 > 3
+ORDER BY total DESC
@@ -0,0 +1,3 @@
+SELECT account_id, MAX(CAST(failed AS INT)) AS any_failed, MIN(CAST(CASE WHEN amount > 1000 THEN 1 ELSE 0 END AS INT)) AS all_large, STDEV(amount) AS spread
+FROM payments
+GROUP BY account_id
@@ -0,0 +1,4 @@
+SELECT accounts.*, CASE WHEN balance < 0 THEN 1 ELSE 0 END AS overdrawn, CASE WHEN active = 1 AND balance < 0 THEN 1 ELSE 0 END AS active_overdrawn, 1 AS verified
+FROM accounts
+WHERE active = 1 AND NOT closed = 1
+ORDER BY CASE WHEN balance < 0 THEN 1 ELSE 0 END
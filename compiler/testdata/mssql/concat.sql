@@ -0,0 +1,2 @@
+SELECT customers.*, CONCAT(first_name, ' ', last_name) AS full_name, LEN(last_name) AS name_length
+FROM customers
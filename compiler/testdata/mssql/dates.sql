@@ -0,0 +1,3 @@
+SELECT invoices.*, DATEADD(day, 30, issued) AS due, DATEADD(week, -1, DATEADD(day, 30, issued)) AS reminder, DATEDIFF(day, issued, CAST('2024-01-31' AS DATE)) AS days_open, DATETRUNC(month, issued) AS month
+FROM invoices
+WHERE issued >= CAST('2024-01-01' AS DATE)
@@ -0,0 +1,2 @@
+SELECT [OrderID], unit_price, quantity, [key]
+FROM [OrderDetails] AS od
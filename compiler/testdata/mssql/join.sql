@@ -0,0 +1,4 @@
+SELECT e.name, c.name, d.title
+FROM employees AS e
+LEFT JOIN countries AS c ON e.country_code = c.country_code
+JOIN departments AS d ON e.dept_id = d.id
@@ -0,0 +1,4 @@
+SELECT *
+FROM employees
+ORDER BY hired
+OFFSET 10 ROWS
@@ -0,0 +1,5 @@
+SELECT *
+FROM employees
+ORDER BY (SELECT NULL)
+OFFSET 10 ROWS
+FETCH NEXT 10 ROWS ONLY
@@ -0,0 +1,3 @@
+SELECT TOP (10) *
+FROM employees
+ORDER BY salary DESC
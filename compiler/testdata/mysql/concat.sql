@@ -0,0 +1,2 @@
+SELECT users.*, CONCAT(first_name, ' (', email, ')') AS label
+FROM users
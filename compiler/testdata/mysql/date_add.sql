@@ -0,0 +1,3 @@
+SELECT subscriptions.*, DATE_ADD(started, INTERVAL 1 YEAR) AS renews, DATE_ADD(expires, INTERVAL 14 DAY) AS grace_ends, DATE_SUB(expires, INTERVAL 7 DAY) AS warned
+FROM subscriptions
+WHERE DATE_ADD(started, INTERVAL 1 YEAR) > DATE '2024-01-01'
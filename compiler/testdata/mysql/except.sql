@@ -0,0 +1,13 @@
+SELECT DISTINCT id
+FROM (
+  SELECT id
+  FROM customers
+) AS table_0
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM (
+    SELECT id
+    FROM banned
+  ) AS table_1
+  WHERE table_0.id <=> table_1.id
+)
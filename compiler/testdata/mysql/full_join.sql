@@ -0,0 +1,8 @@
+SELECT budgets.account, budgets.amount, actuals.amount AS actual_amount
+FROM budgets
+LEFT JOIN actuals ON budgets.account = actuals.account
+UNION ALL
+SELECT budgets.account, budgets.amount, actuals.amount AS actual_amount
+FROM budgets
+RIGHT JOIN actuals ON budgets.account = actuals.account
+WHERE (budgets.account = actuals.account) IS NOT TRUE
@@ -0,0 +1,4 @@
+SELECT *
+FROM orders
+ORDER BY created DESC
+LIMIT 20, 10
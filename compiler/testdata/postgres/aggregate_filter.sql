@@ -0,0 +1,3 @@
+SELECT customer_id, COUNT(*) AS orders, COUNT(*) FILTER (WHERE status = 'paid') AS paid_orders, SUM(amount) FILTER (WHERE status = 'paid') AS paid_total, COUNT(DISTINCT order_id) FILTER (WHERE refunded) AS refunded
+FROM orders
+GROUP BY customer_id
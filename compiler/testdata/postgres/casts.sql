@@ -0,0 +1,2 @@
+SELECT orders.*, quantity::DOUBLE PRECISION / total_quantity AS share, (price - discount)::DOUBLE PRECISION / quantity AS unit_price, 2.0 / quantity AS rate
+FROM orders
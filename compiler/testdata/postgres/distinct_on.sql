@@ -0,0 +1,3 @@
+SELECT DISTINCT ON (department) *
+FROM employees
+ORDER BY department, salary DESC
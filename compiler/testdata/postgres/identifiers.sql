@@ -0,0 +1,2 @@
+SELECT "CustomerID", first_name, last_name AS "LastName", "order"
+FROM "Customers"
@@ -0,0 +1,3 @@
+SELECT *
+FROM customers
+WHERE name ILIKE '%Smith%' AND email ILIKE 'Admin\_%' AND STRPOS(LOWER(name), LOWER(nickname)) > 0
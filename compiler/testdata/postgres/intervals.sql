@@ -0,0 +1,3 @@
+SELECT events.*, created + INTERVAL '2 weeks' AS due, created + INTERVAL '1 day' AS next, created - INTERVAL '500 milliseconds' AS earlier, COUNT(*) OVER (ORDER BY created RANGE BETWEEN INTERVAL '7 days' PRECEDING AND CURRENT ROW) AS recent
+FROM events
+ORDER BY created
@@ -0,0 +1,4 @@
+SELECT day.value, SUM(orders.amount) AS total
+FROM GENERATE_SERIES(0, 6) AS day(value)
+JOIN orders ON day.value = orders.weekday
+GROUP BY day.value
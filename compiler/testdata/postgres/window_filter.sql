@@ -0,0 +1,2 @@
+SELECT orders.*, SUM(amount) FILTER (WHERE status = 'paid') OVER (PARTITION BY customer_id ORDER BY created ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS paid_to_date
+FROM orders
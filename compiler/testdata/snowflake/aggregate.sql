@@ -0,0 +1,3 @@
+SELECT customer, COUNT_IF(DATEADD(week, 2, created) < shipped) AS late, BOOLOR_AGG(shipped > due) AS some_late
+FROM orders
+GROUP BY customer
@@ -0,0 +1,3 @@
+SELECT orders.*, DATEADD(week, 2, created) AS due, DATEADD(hour, -3, created) AS earlier, IFF(total > 100, 'high', 'low') AS tier, CASE WHEN total > 1000 THEN TRUE END AS large
+FROM orders
+WHERE customer ILIKE '%inc%'
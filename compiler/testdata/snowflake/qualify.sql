@@ -0,0 +1,3 @@
+SELECT * EXCLUDE (notes)
+FROM orders
+QUALIFY ROW_NUMBER() OVER (PARTITION BY customer_id ORDER BY created DESC) <= 1
@@ -0,0 +1,3 @@
+SELECT users.*, 1 AS active, role = 'admin' AS admin, DATE(joined) AS day, CAST(score > 10 AS INTEGER) AS flag
+FROM users
+WHERE verified = 0 AND NOT deleted
@@ -0,0 +1,3 @@
+SELECT orders.*, DATE(created, 'start of month') AS month, CAST(STRFTIME('%Y', created) AS INTEGER) AS year, STRFTIME('%Y-%m-%d', created) AS label, DATETIME(created, '+14 days') AS due, DATETIME(DATETIME('2024-01-01T09:00'), '-30 minutes') AS reminder, CAST(JULIANDAY(shipped) - JULIANDAY(created) AS INTEGER) AS days, (STRFTIME('%s', shipped) - STRFTIME('%s', created)) / 3600 AS hours
+FROM orders
+WHERE created >= DATE('2024-01-01') AND opened_at < TIME('12:30')
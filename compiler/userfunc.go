@@ -0,0 +1,187 @@
+package compiler
+
+import (
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// declareFunctions registers the functions declared by the query. A function
+// may not call itself, as it's name is only declared after it's body.
+func (c *compiler) declareFunctions(functions []*syntax.Function) error {
+	c.functions = make(map[string]*syntax.Function, len(functions))
+	for _, fn := range functions {
+		if _, exists := c.functions[fn.Name]; exists {
+			return errorf(fn.Pos(), "function '%s' is already declared", fn.Name)
+		}
+		if pos, ok := callsItself(fn); ok {
+			return errorf(pos, "function '%s' cannot call itself", fn.Name)
+		}
+		c.functions[fn.Name] = fn
+	}
+	return nil
+}
+
+// callsItself returns the position where the function's body references it's
+// own name, unless a parameter of the same name hides it.
+func callsItself(fn *syntax.Function) (syntax.Position, bool) {
+	for _, param := range fn.Positional {
+		if param == fn.Name {
+			return syntax.Position{}, false
+		}
+	}
+	for _, param := range fn.Named {
+		if param.Name == fn.Name {
+			return syntax.Position{}, false
+		}
+	}
+
+	var pos syntax.Position
+	found := false
+	mapSyntax(fn.Body, func(e syntax.Expr) (syntax.Expr, bool) {
+		switch v := e.(type) {
+		case syntax.Call:
+			found = found || v.Name == fn.Name
+		case syntax.Ident:
+			found = found || v.Name == fn.Name
+		}
+		if found && pos.Line == 0 {
+			pos = e.Pos()
+		}
+		return e, found
+	})
+	return pos, found
+}
+
+// callDeclared inlines the body of a declared function, replacing each of the
+// parameters with the arguments given. Named parameters which are not given
+// use their default values.
+func (r *relation) callDeclared(fn *syntax.Function, call syntax.Call) (sqlExpr, error) {
+	if call.Pos().Before(fn.Pos()) {
+		return nil, errorf(call.Pos(), "function '%s' is called before it is declared", fn.Name)
+	}
+
+	args := make(map[string]syntax.Expr, len(fn.Named)+len(fn.Positional))
+	for _, param := range fn.Named {
+		args[param.Name] = param.Default
+	}
+	for name, value := range call.Named {
+		if _, ok := args[name]; !ok {
+			return nil, errorf(value.Pos(), "function '%s' has no parameter named '%s'", fn.Name, name)
+		}
+		args[name] = value
+	}
+
+	if len(call.Args) < len(fn.Positional) {
+		return nil, errorf(call.Pos(), "function '%s' is missing the argument '%s'", fn.Name, fn.Positional[len(call.Args)])
+	}
+	if len(call.Args) > len(fn.Positional) {
+		return nil, errorf(call.Args[len(fn.Positional)].Pos(), "function '%s' expects %d positional arguments but received %d", fn.Name, len(fn.Positional), len(call.Args))
+	}
+	for i, param := range fn.Positional {
+		args[param] = call.Args[i]
+	}
+
+	body := mapSyntax(fn.Body, func(e syntax.Expr) (syntax.Expr, bool) {
+		if ident, ok := e.(syntax.Ident); ok {
+			if arg, ok := args[ident.Name]; ok {
+				return arg, true
+			}
+		}
+		return e, false
+	})
+	return r.translate(body)
+}
+
+// translatePipe converts a pipeline of values within an expression, such as
+// "(deg_c | celsius_to_fahrenheit)". Each stage after the first calls a
+// function with the value before it as the last positional argument.
+func (r *relation) translatePipe(e syntax.Pipeline) (sqlExpr, error) {
+	value := e.Stages[0]
+	for _, stage := range e.Stages[1:] {
+		var call syntax.Call
+		switch s := stage.(type) {
+		case syntax.Ident:
+			call = syntax.Call{Position: s.Position, Name: s.Name}
+		case syntax.Call:
+			call = s
+		default:
+			return nil, errorf(stage.Pos(), "expected a function call but found %s", stage.String())
+		}
+
+		// Constant functions discard the value piped into them
+		if fn, ok := r.c.functions[call.Name]; ok && len(fn.Positional) == 0 {
+			r.c.warnf(call.Pos(), "the value piped into '%s' is discarded as it has no positional parameters", call.Name)
+			value = call
+			continue
+		}
+
+		call.Args = append(call.Args[:len(call.Args):len(call.Args)], value)
+		value = call
+	}
+	return r.translate(value)
+}
+
+// mapSyntax rebuilds the PRQL expression, replacing each node with the result
+// of fn. Nodes are visited top down, and when fn returns true for "done" the
+// node returned replaces the original without visiting it's children.
+func mapSyntax(expr syntax.Expr, fn func(syntax.Expr) (syntax.Expr, bool)) syntax.Expr {
+	if expr == nil {
+		return nil
+	}
+	if replaced, done := fn(expr); done {
+		return replaced
+	}
+
+	each := func(exprs []syntax.Expr) []syntax.Expr {
+		mapped := make([]syntax.Expr, len(exprs))
+		for i, e := range exprs {
+			mapped[i] = mapSyntax(e, fn)
+		}
+		return mapped
+	}
+
+	switch e := expr.(type) {
+	case syntax.Binary:
+		e.Left = mapSyntax(e.Left, fn)
+		e.Right = mapSyntax(e.Right, fn)
+		return e
+	case syntax.Unary:
+		e.Operand = mapSyntax(e.Operand, fn)
+		return e
+	case syntax.Range:
+		e.Start = mapSyntax(e.Start, fn)
+		e.End = mapSyntax(e.End, fn)
+		return e
+	case syntax.List:
+		e.Items = each(e.Items)
+		return e
+	case syntax.Assign:
+		e.Value = mapSyntax(e.Value, fn)
+		return e
+	case syntax.Call:
+		e.Args = each(e.Args)
+		named := make(map[string]syntax.Expr, len(e.Named))
+		for name, value := range e.Named {
+			named[name] = mapSyntax(value, fn)
+		}
+		e.Named = named
+		return e
+	case syntax.Pipeline:
+		e.Stages = each(e.Stages)
+		return e
+	case syntax.Case:
+		arms := make([]syntax.CaseArm, len(e.Arms))
+		for i, arm := range e.Arms {
+			arms[i] = syntax.CaseArm{Condition: mapSyntax(arm.Condition, fn), Value: mapSyntax(arm.Value, fn)}
+		}
+		e.Arms = arms
+		return e
+	case syntax.Interpolation:
+		parts := make([]syntax.InterpolationPart, len(e.Parts))
+		for i, part := range e.Parts {
+			parts[i] = syntax.InterpolationPart{Text: part.Text, Expr: mapSyntax(part.Expr, fn)}
+		}
+		e.Parts = parts
+		return e
+	}
+	return expr
+}
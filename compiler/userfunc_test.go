@@ -0,0 +1,84 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+)
+
+func TestCompileFunctions(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "explicit invocation",
+			source: `
+func interpolate low:0 high val -> (val - low) / (high - low)
+from readings
+derive [a = (interpolate 100 temp), b = (interpolate low:10 100 temp)]`,
+			expected: `
+SELECT readings.*, (temp - 0) / (100 - 0) AS a, (temp - 10) / (100 - 10) AS b
+FROM readings`,
+		},
+		{
+			name: "implicit invocation",
+			source: `
+func celsius_to_fahrenheit deg_c -> deg_c * 1.8 + 32
+func interpolate low:0 high val -> (val - low) / (high - low)
+from weather
+derive [deg_f = (deg_c | celsius_to_fahrenheit), scaled = (deg_c | celsius_to_fahrenheit | interpolate 212)]`,
+			expected: `
+SELECT weather.*, deg_c * 1.8 + 32 AS deg_f, (deg_c * 1.8 + 32 - 0) / (212 - 0) AS scaled
+FROM weather`,
+		},
+		{
+			name: "constants and composition",
+			source: `
+func pi -> 3.14
+func deg_to_rad deg -> deg * (pi / 180)
+from angles
+select [rad = (deg_to_rad angle), pi]`,
+			expected: `
+SELECT angle * (3.14 / 180) AS rad, 3.14 AS pi
+FROM angles`,
+		},
+		{
+			name: "parameters hide columns",
+			source: `
+func double x -> x * 2
+from t
+derive [x = 5, y = (double (x + 1))]`,
+			expected: `
+SELECT t.*, 5 AS x, (5 + 1) * 2 AS y
+FROM t`,
+		},
+	})
+}
+
+func TestCompileFunctionErrors(t *testing.T) {
+	compileError(t, "func f x -> (f x)\nfrom t", "1:14: function 'f' cannot call itself")
+	compileError(t, "func f x -> x\nfunc f y -> y\nfrom t", "2:1: function 'f' is already declared")
+	compileError(t, "func a x -> (b x)\nfunc b x -> x\nfrom t\nderive [y = (a z)]", "1:14: function 'b' is called before it is declared")
+	compileError(t, "func f low:0 x -> x\nfrom t\nderive [y = f]", "3:13: function 'f' is missing the argument 'x'")
+	compileError(t, "func f x -> x\nfrom t\nderive [y = (f a b)]", "3:18: function 'f' expects 1 positional arguments but received 2")
+	compileError(t, "func f x -> x\nfrom t\nderive [y = (f high:1 a)]", "function 'f' has no parameter named 'high'")
+}
+
+func TestCompileFunctionWarnings(t *testing.T) {
+	query, err := parser.Parse("func pi -> 3.14\nfrom t\nderive [a = (b | pi)]")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	sql, warnings, err := compiler.CompileWithWarnings(query, compiler.Options{})
+	if err != nil {
+		t.Fatalf("unexpected compile error: %s", err)
+	}
+	if expected := "SELECT t.*, 3.14 AS a\nFROM t"; sql != expected {
+		t.Errorf("unexpected SQL\nexpected:\n%s\nreceived:\n%s", expected, sql)
+	}
+
+	expected := "3:18: the value piped into 'pi' is discarded as it has no positional parameters"
+	if len(warnings) != 1 || warnings[0].String() != expected {
+		t.Errorf("expected warning %q, received %v", expected, warnings)
+	}
+}
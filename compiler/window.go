@@ -0,0 +1,224 @@
+package compiler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// windowSpec describes the window which aggregate and window functions are
+// computed over. The partition holds the grouping columns, and order the sort
+// transform given, otherwise the current order of the relation is used.
+type windowSpec struct {
+	partition []syntax.Expr
+	order     *syntax.Call
+	frame     *windowFrame
+}
+
+// windowParams holds the named parameters accepted by "window"
+var windowParams = []string{"rows", "range", "rolling", "expanding"}
+
+// window applies a nested pipeline of transforms over a frame of rows. Within
+// a group, the partition and order of the group are used.
+//
+// The frame is given by one of the named parameters:
+//
+//	rows:-3..0      ROWS BETWEEN 3 PRECEDING AND CURRENT ROW
+//	range:-10..10   RANGE BETWEEN 10 PRECEDING AND 10 FOLLOWING
+//	rolling:7       ROWS BETWEEN 6 PRECEDING AND CURRENT ROW
+//	expanding:true  ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+func (r *relation) window(call syntax.Call, partition []syntax.Expr, order *syntax.Call) error {
+	arg, err := singleArg(call)
+	if err != nil {
+		return err
+	}
+	frame, err := windowFrameOf(call)
+	if err != nil {
+		return err
+	}
+	if frame != nil && frame.kind == "RANGE" && (frame.start.offset != nil || frame.end.offset != nil) &&
+		!r.c.target.Supports(CapabilityWindowFrames) {
+		return r.c.unsupported(call.Named["range"].Pos(), "'range' frame bounded by offsets")
+	}
+
+	for _, stage := range pipelineStages(arg) {
+		transform, ok := stage.(syntax.Call)
+		if !ok {
+			return errorf(stage.Pos(), "expected a transform but found %s", stage.String())
+		}
+
+		switch transform.Name {
+		case "sort":
+			sortCall := transform
+			order = &sortCall
+		case "derive":
+			err = r.derive(transform, &windowSpec{partition, order, frame})
+		case "select":
+			err = r.selectColumns(transform, &windowSpec{partition, order, frame})
+		default:
+			err = errorf(transform.Pos(), "'%s' is not supported within 'window'", transform.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowFrameOf reads the frame from the named parameters of "window". When
+// none are given, nil is returned so the default frame is used.
+func windowFrameOf(call syntax.Call) (*windowFrame, error) {
+	for name, value := range call.Named {
+		known := false
+		for _, param := range windowParams {
+			known = known || name == param
+		}
+		if !known {
+			return nil, errorf(value.Pos(), "'window' has no parameter named '%s'", name)
+		}
+	}
+
+	var frame *windowFrame
+	for _, name := range windowParams {
+		value, ok := call.Named[name]
+		if !ok {
+			continue
+		}
+		if frame != nil {
+			return nil, errorf(value.Pos(), "'window' accepts only one of rows, range, rolling or expanding")
+		}
+
+		var err error
+		switch name {
+		case "rows":
+			frame, err = frameBetween("ROWS", value, false)
+		case "range":
+			frame, err = frameBetween("RANGE", value, true)
+		case "rolling":
+			frame, err = rollingFrame(value)
+		case "expanding":
+			frame, err = expandingFrame(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return frame, nil
+}
+
+// frameBetween reads a frame from a range of offsets relative to the current
+// row. Open ends of the range are unbounded. Intervals are only accepted by
+// "RANGE" frames.
+func frameBetween(kind string, value syntax.Expr, intervals bool) (*windowFrame, error) {
+	rng, ok := value.(syntax.Range)
+	if !ok {
+		return nil, errorf(value.Pos(), "'%s' expects a range but found %s", strings.ToLower(kind), value.String())
+	}
+
+	start, err := frameBoundOf(rng.Start, true, intervals)
+	if err != nil {
+		return nil, err
+	}
+	end, err := frameBoundOf(rng.End, false, intervals)
+	if err != nil {
+		return nil, err
+	}
+	return &windowFrame{kind: kind, start: start, end: end}, nil
+}
+
+// frameBoundOf reads a single bound of a frame range. A missing bound is
+// unbounded, preceding the current row for the start of the range.
+func frameBoundOf(expr syntax.Expr, start, intervals bool) (frameBound, error) {
+	if expr == nil {
+		return frameBound{unbounded: true, preceding: start}, nil
+	}
+
+	negative := false
+	if unary, ok := expr.(syntax.Unary); ok && (unary.Op == "-" || unary.Op == "+") {
+		negative = unary.Op == "-"
+		expr = unary.Operand
+	}
+
+	switch e := expr.(type) {
+	case syntax.Literal:
+		if e.Type != syntax.TypeInteger && e.Type != syntax.TypeFloat {
+			break
+		}
+		if value, err := strconv.ParseFloat(e.Value, 64); err == nil && value == 0 {
+			return frameBound{}, nil
+		}
+		return frameBound{offset: literalExpr{e.Type, e.Value}, preceding: negative}, nil
+
+	case syntax.Interval:
+		if !intervals {
+			return frameBound{}, errorf(e.Pos(), "'rows' expects a number of rows but found the interval %s", e.String())
+		}
+		return frameBound{offset: intervalExpr{e.Value, e.Unit}, preceding: negative}, nil
+	}
+	return frameBound{}, errorf(expr.Pos(), "window frame bounds must be constant offsets but found %s", expr.String())
+}
+
+// rollingFrame reads the number of rows for a rolling frame, which ends at the
+// current row.
+func rollingFrame(value syntax.Expr) (*windowFrame, error) {
+	lit, ok := value.(syntax.Literal)
+	if !ok || lit.Type != syntax.TypeInteger {
+		return nil, errorf(value.Pos(), "'rolling' expects a number of rows but found %s", value.String())
+	}
+	count, err := strconv.ParseInt(lit.Value, 10, 64)
+	if err != nil || count < 1 {
+		return nil, errorf(value.Pos(), "'rolling' must be at least 1 row but found %s", lit.Value)
+	}
+
+	start := frameBound{}
+	if count > 1 {
+		start = frameBound{offset: literalInt(count - 1), preceding: true}
+	}
+	return &windowFrame{kind: "ROWS", start: start, end: frameBound{}}, nil
+}
+
+// expandingFrame reads whether the frame expands from the start of the
+// partition up to the current row.
+func expandingFrame(value syntax.Expr) (*windowFrame, error) {
+	lit, ok := value.(syntax.Literal)
+	if !ok || lit.Type != syntax.TypeBoolean {
+		return nil, errorf(value.Pos(), "'expanding' expects true or false but found %s", value.String())
+	}
+	if lit.Value != "true" {
+		return nil, nil
+	}
+	return &windowFrame{
+		kind:  "ROWS",
+		start: frameBound{unbounded: true, preceding: true},
+		end:   frameBound{},
+	}, nil
+}
+
+// windowed wraps any aggregate or window functions within the expression into
+// a window over the partition. Without a frame, only window functions use the
+// order, so that aggregates apply to the whole partition. The frame is only
+// applied to aggregates and the window functions which accept one.
+func windowed(expr sqlExpr, partition []sqlExpr, order []sortItem, frame *windowFrame) sqlExpr {
+	return mapExpr(expr, func(e sqlExpr) (sqlExpr, bool) {
+		switch fn := e.(type) {
+		case windowExpr:
+			return fn, true
+		case funcExpr:
+			if fn.window {
+				win := windowExpr{fn: fn, partition: partition, order: order}
+				if fn.framed {
+					win.frame = frame
+				}
+				return win, true
+			}
+			if fn.aggregate {
+				if frame == nil {
+					return windowExpr{fn: fn, partition: partition}, true
+				}
+				return windowExpr{fn: fn, partition: partition, order: order, frame: frame}, true
+			}
+		}
+		return e, false
+	})
+}
@@ -0,0 +1,101 @@
+package compiler_test
+
+import "testing"
+
+func TestCompileWindow(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "rolling",
+			source: `
+from prices
+sort date
+window rolling:7 (derive [avg7 = average price])`,
+			expected: `
+SELECT prices.*, AVG(price) OVER (ORDER BY date ROWS BETWEEN 6 PRECEDING AND CURRENT ROW) AS avg7
+FROM prices
+ORDER BY date`,
+		},
+		{
+			name: "rows",
+			source: `
+from t
+sort d
+window rows:-3..0 (derive [s = sum x])`,
+			expected: `
+SELECT t.*, SUM(x) OVER (ORDER BY d ROWS BETWEEN 3 PRECEDING AND CURRENT ROW) AS s
+FROM t
+ORDER BY d`,
+		},
+		{
+			name: "open rows",
+			source: `
+from t
+window rows:..1 (derive [s = sum x])`,
+			expected: `
+SELECT t.*, SUM(x) OVER (ROWS BETWEEN UNBOUNDED PRECEDING AND 1 FOLLOWING) AS s
+FROM t`,
+		},
+		{
+			name: "range of intervals",
+			source: `
+from t
+sort d
+window range:-7days..0 (derive [s = sum x])`,
+			expected: `
+SELECT t.*, SUM(x) OVER (ORDER BY d RANGE BETWEEN INTERVAL '7' DAY PRECEDING AND CURRENT ROW) AS s
+FROM t
+ORDER BY d`,
+		},
+		{
+			name: "default frame",
+			source: `
+from t
+window (derive [s = sum x])`,
+			expected: `
+SELECT t.*, SUM(x) OVER () AS s
+FROM t`,
+		},
+		{
+			name: "expanding within group",
+			source: `
+from employees
+group dept (sort age | window expanding:true (derive [running = sum salary, rn = row_number, fv = first_value name]))`,
+			expected: `
+SELECT employees.*, SUM(salary) OVER (PARTITION BY dept ORDER BY age ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS running, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY age) AS rn, FIRST_VALUE(name) OVER (PARTITION BY dept ORDER BY age ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS fv
+FROM employees`,
+		},
+		{
+			name: "sort and select within window",
+			source: `
+from t
+window rows:-1..1 (sort -d | select [x, s = sum x])`,
+			expected: `
+SELECT x, SUM(x) OVER (ORDER BY d DESC ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) AS s
+FROM t`,
+		},
+	})
+}
+
+func TestCompileWindowErrors(t *testing.T) {
+	compileError(t, "from t\nwindow rows:..1 rolling:2 (derive [s = sum x])", "'window' accepts only one of rows, range, rolling or expanding")
+	compileError(t, "from t\nwindow rows:-1days..0 (derive [s = sum x])", "2:14: 'rows' expects a number of rows but found the interval 1days")
+	compileError(t, "from t\nwindow rolling:0 (derive [s = sum x])", "2:16: 'rolling' must be at least 1 row but found 0")
+	compileError(t, "from t\nwindow frame:1 (derive [s = sum x])", "2:14: 'window' has no parameter named 'frame'")
+	compileError(t, "from t\nwindow (take 1)", "2:9: 'take' is not supported within 'window'")
+}
+
+func TestCompileWindowFrames(t *testing.T) {
+	runCompileTests(t, []compileTest{
+		{
+			name: "unbounded range",
+			source: `prql dialect:mssql
+from t
+window range:..0 (derive [s = sum x])`,
+			expected: `
+SELECT t.*, SUM(x) OVER (RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS s
+FROM t`,
+		},
+	})
+
+	compileError(t, "prql dialect:mssql\nfrom t\nwindow range:-10..0 (derive [s = sum x])", "3:14: 'range' frame bounded by offsets in 'window' is not supported by dialect mssql")
+}
@@ -22,6 +22,19 @@ const (
 	//
 	// Encoded as "SYNTAX"
 	ErrorTypeSyntax
+
+	// ErrorTypeCompile signifies the error was generated while compiling the
+	// parsed query into SQL, such as an unknown name or an invalid use of a
+	// transform. These are client errors relating to the input PRQL query.
+	//
+	// Encoded as "COMPILE"
+	ErrorTypeCompile
+
+	// ErrorTypeUnsupported signifies the query is valid, but uses a feature
+	// which the target SQL dialect does not support and cannot be emulated.
+	//
+	// Encoded as "UNSUPPORTED"
+	ErrorTypeUnsupported
 )
 
 // String returns a string representation of the ErrorType enum. By default, Go
@@ -30,6 +43,10 @@ func (t ErrorType) String() string {
 	switch t {
 	case ErrorTypeSyntax:
 		return "SYNTAX"
+	case ErrorTypeCompile:
+		return "COMPILE"
+	case ErrorTypeUnsupported:
+		return "UNSUPPORTED"
 	}
 
 	return "UNKNOWN"
@@ -39,7 +56,7 @@ func (t ErrorType) String() string {
 // known errors. This excludes the ErrorTypeUnknown constant, as those are
 // reserved for truely uknown or zero-value errors.
 func (t ErrorType) Valid() bool {
-	return t > ErrorTypeUnknown && t <= ErrorTypeSyntax
+	return t > ErrorTypeUnknown && t <= ErrorTypeUnsupported
 }
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface. Allows for
@@ -50,6 +67,10 @@ func (t *ErrorType) UnmarshalText(text []byte) error {
 	switch str {
 	case "SYNTAX":
 		*t = ErrorTypeSyntax
+	case "COMPILE":
+		*t = ErrorTypeCompile
+	case "UNSUPPORTED":
+		*t = ErrorTypeUnsupported
 	default:
 		*t = ErrorTypeUnknown
 		return fmt.Errorf("ErrorType '%s' is invalid", str)
@@ -0,0 +1,16 @@
+package parser
+
+import "fmt"
+
+// SyntaxError is returned when the source cannot be tokenized or parsed, such
+// as an unterminated string literal.
+type SyntaxError struct {
+	Line      uint
+	Character uint
+	Message   string
+}
+
+// Error implements the `error` interface.
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Character, e.Message)
+}
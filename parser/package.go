@@ -3,18 +3,20 @@
 package parser
 
 import (
-	"github.com/chris-pikul/go-prql"
+	"github.com/chris-pikul/go-prql/syntax"
 )
 
-// AST Dummy type alias.
-type AST = map[string]string
-
 // Parse takes the incoming PRQL query as a string, and attempts to
 // parse/tokenize it into a working AST.
 //
-// Returns the AST, and a PRQLError for any errors occuring during parsing.
-func Parse(source string) (AST, *prql.Error) {
+// Returns the AST, and a SyntaxError for any errors occuring during parsing.
+func Parse(source string) (*syntax.Query, error) {
 	// Tokenize the input to normalize it
-	tokenize(source)
-	return nil, nil
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	return p.parseQuery()
 }
@@ -0,0 +1,754 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chris-pikul/go-prql/syntax"
+	"github.com/chris-pikul/go-prql/utils"
+)
+
+// intervalUnits holds the accepted suffixes for interval literals such as
+// "7days".
+var intervalUnits = map[string]bool{
+	"microseconds": true,
+	"milliseconds": true,
+	"seconds":      true,
+	"minutes":      true,
+	"hours":        true,
+	"days":         true,
+	"weeks":        true,
+	"months":       true,
+	"years":        true,
+}
+
+// parser holds the state while building the syntax tree from tokens
+type parser struct {
+	tokens Tokens
+	pos    int
+}
+
+// peek returns the token at the given offset from the current position. Past
+// the end of the tokens a zero-value Token (TokenTypeUnknown) is returned.
+func (p *parser) peek(offset int) Token {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return Token{}
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) advance() Token {
+	tkn := p.peek(0)
+	p.pos++
+	return tkn
+}
+
+// isOp checks if the current token is the operator given
+func (p *parser) isOp(op string) bool {
+	tkn := p.peek(0)
+	return tkn.Type == TokenTypeOperator && tkn.Value == op
+}
+
+// expectOp consumes the given operator, or returns an error
+func (p *parser) expectOp(op string) error {
+	if !p.isOp(op) {
+		return p.errorf(p.peek(0), "expected '%s' but found %s", op, describe(p.peek(0)))
+	}
+	p.advance()
+	return nil
+}
+
+func (p *parser) skipPipes() {
+	for p.peek(0).Type == TokenTypePipe {
+		p.advance()
+	}
+}
+
+func (p *parser) errorf(tkn Token, format string, args ...any) error {
+	if tkn.Line == 0 && len(p.tokens) > 0 {
+		// Past the end, report the position of the last token
+		last := p.tokens[len(p.tokens)-1]
+		tkn.Line = last.Line
+		tkn.Character = last.Character + uint(len(last.Value))
+	}
+	return SyntaxError{tkn.Line, tkn.Character, fmt.Sprintf(format, args...)}
+}
+
+// describe returns a human readable description of a token for errors
+func describe(tkn Token) string {
+	if tkn.Type == TokenTypeUnknown {
+		return "end of input"
+	}
+	return fmt.Sprintf("'%s'", tkn.Value)
+}
+
+func position(tkn Token) syntax.Position {
+	return syntax.Position{Line: tkn.Line, Character: tkn.Character}
+}
+
+// isName returns true if the token can be used as a name (identifier)
+func isName(tkn Token) bool {
+	return tkn.Type == TokenTypeGeneric || tkn.Type == TokenTypeKeyword
+}
+
+// parseQuery parses the whole document
+func (p *parser) parseQuery() (*syntax.Query, error) {
+	query := &syntax.Query{}
+
+	p.skipPipes()
+	if tkn := p.peek(0); tkn.Type == TokenTypeKeyword && tkn.Value == "prql" {
+		header, err := p.parseHeader()
+		if err != nil {
+			return nil, err
+		}
+		query.Header = *header
+	}
+
+	p.skipPipes()
+	for tkn := p.peek(0); tkn.Type == TokenTypeKeyword; tkn = p.peek(0) {
+		if tkn.Value == "func" {
+			fn, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			query.Functions = append(query.Functions, fn)
+		} else if tkn.Value == "let" || tkn.Value == "table" {
+			table, err := p.parseTable()
+			if err != nil {
+				return nil, err
+			}
+			query.Tables = append(query.Tables, table)
+		} else {
+			break
+		}
+		p.skipPipes()
+	}
+
+	main, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf(p.peek(0), "unexpected %s", describe(p.peek(0)))
+	}
+	if len(main.Stages) == 0 {
+		return nil, p.errorf(p.peek(0), "query has no pipeline")
+	}
+	query.Main = main
+
+	return query, nil
+}
+
+// parseHeader parses the "prql" directive and it's named parameters
+func (p *parser) parseHeader() (*syntax.Header, error) {
+	p.advance()
+	header := &syntax.Header{}
+
+	for !p.done() && p.peek(0).Type != TokenTypePipe {
+		name := p.advance()
+		if !isName(name) || !p.isOp(":") {
+			return nil, p.errorf(name, "expected named parameter in prql header but found %s", describe(name))
+		}
+		p.advance()
+		value := p.advance()
+
+		switch name.Value {
+		case "dialect":
+			// Unknown dialects are recoverable and default to generic
+			if err := header.Dialect.UnmarshalText([]byte(value.Value)); err != nil {
+				header.Dialect = syntax.DialectGeneric
+			}
+		case "version":
+			ver, err := strconv.Atoi(value.Value)
+			if err != nil || value.Type != TokenTypeNumber {
+				return nil, p.errorf(value, "prql version must be an integer")
+			}
+			header.Version = utils.NewOptional(&ver, true)
+		default:
+			return nil, p.errorf(name, "unknown prql header parameter '%s'", name.Value)
+		}
+	}
+
+	return header, nil
+}
+
+// parseFunction parses a "func" declaration. Any named parameters and their
+// defaults are declared before the positional parameters.
+func (p *parser) parseFunction() (*syntax.Function, error) {
+	keyword := p.advance()
+	name := p.advance()
+	if name.Type != TokenTypeGeneric || strings.Contains(name.Value, ".") {
+		return nil, p.errorf(name, "expected a function name after 'func' but found %s", describe(name))
+	}
+	fn := &syntax.Function{Position: position(keyword), Name: name.Value}
+
+	declared := map[string]bool{}
+	for !p.isOp("->") {
+		param := p.advance()
+		if param.Type != TokenTypeGeneric || strings.Contains(param.Value, ".") {
+			return nil, p.errorf(param, "expected a parameter or '->' but found %s", describe(param))
+		}
+		if declared[param.Value] {
+			return nil, p.errorf(param, "parameter '%s' is already declared", param.Value)
+		}
+		declared[param.Value] = true
+
+		if !p.isOp(":") {
+			fn.Positional = append(fn.Positional, param.Value)
+			continue
+		}
+		if len(fn.Positional) > 0 {
+			return nil, p.errorf(param, "named parameter '%s' must be declared before the positional parameters", param.Value)
+		}
+		p.advance()
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		fn.Named = append(fn.Named, syntax.NamedParam{Name: param.Value, Default: value})
+	}
+	p.advance()
+
+	body, err := p.parseCallOrExpr(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() && p.peek(0).Type != TokenTypePipe {
+		return nil, p.errorf(p.peek(0), "unexpected %s", describe(p.peek(0)))
+	}
+	fn.Body = body
+	return fn, nil
+}
+
+// parseTable parses a "let" or "table" declaration of a named relation. A
+// pipeline of more than one stage must be within parenthesis.
+func (p *parser) parseTable() (*syntax.Table, error) {
+	keyword := p.advance()
+	name := p.advance()
+	if name.Type != TokenTypeGeneric || strings.Contains(name.Value, ".") {
+		return nil, p.errorf(name, "expected a name after '%s' but found %s", keyword.Value, describe(name))
+	}
+	if err := p.expectOp("="); err != nil {
+		return nil, err
+	}
+
+	start := p.peek(0)
+	value, err := p.parseCallOrExpr(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() && p.peek(0).Type != TokenTypePipe {
+		return nil, p.errorf(p.peek(0), "unexpected %s", describe(p.peek(0)))
+	}
+
+	table := &syntax.Table{Position: position(keyword), Name: name.Value}
+	switch v := value.(type) {
+	case syntax.Pipeline:
+		table.Pipeline = &v
+	case syntax.Call:
+		table.Pipeline = &syntax.Pipeline{Position: v.Position, Stages: []syntax.Expr{v}}
+	default:
+		return nil, p.errorf(start, "'%s' expects a pipeline but found %s", keyword.Value, value.String())
+	}
+	return table, nil
+}
+
+// parsePipeline parses stages separated by pipes until the end of the input or
+// a closing parenthesis.
+func (p *parser) parsePipeline() (*syntax.Pipeline, error) {
+	pipeline := &syntax.Pipeline{Position: position(p.peek(0))}
+
+	for !p.done() && !p.isOp(")") {
+		stage, err := p.parseCallOrExpr(true)
+		if err != nil {
+			return nil, err
+		}
+		if ident, ok := stage.(syntax.Ident); ok && keywords[ident.Name] {
+			// A transform without any arguments, such as a bare "take"
+			stage = syntax.Call{Position: ident.Position, Name: ident.Name, Named: make(map[string]syntax.Expr)}
+		}
+		pipeline.Stages = append(pipeline.Stages, stage)
+
+		if p.peek(0).Type == TokenTypePipe {
+			p.skipPipes()
+		} else if !p.done() && !p.isOp(")") {
+			return nil, p.errorf(p.peek(0), "unexpected %s", describe(p.peek(0)))
+		}
+	}
+
+	return pipeline, nil
+}
+
+// startsArgument returns true if the current token can begin a call argument.
+//
+// Prefix operators such as "-" only begin an argument when they are attached
+// to what follows, and are separated from what comes before. This allows
+// "sort -date" to be a call, while "a - b" is a subtraction.
+func (p *parser) startsArgument() bool {
+	tkn := p.peek(0)
+	switch tkn.Type {
+	case TokenTypeGeneric, TokenTypeKeyword, TokenTypeString, TokenTypeFString,
+		TokenTypeSString, TokenTypeNumber, TokenTypeDate:
+		return true
+	case TokenTypeOperator:
+		switch tkn.Value {
+		case "[", "(":
+			return true
+		case "-", "+", "!", "==":
+			next := p.peek(1)
+			prev := p.peek(-1)
+			attached := next.Line == tkn.Line && next.Character == tkn.Character+uint(len(tkn.Value))
+			separated := prev.Line != tkn.Line || prev.Character+uint(len([]rune(prev.Value))) < tkn.Character
+			return attached && separated
+		}
+	}
+	return false
+}
+
+// parseCallOrExpr parses either a function call (name followed by arguments)
+// or a plain expression. When allowAssign is true, an "name = ..." assignment
+// is also accepted.
+func (p *parser) parseCallOrExpr(allowAssign bool) (syntax.Expr, error) {
+	tkn := p.peek(0)
+
+	if allowAssign && isName(tkn) && p.peek(1).Type == TokenTypeOperator && p.peek(1).Value == "=" {
+		p.advance()
+		p.advance()
+		value, err := p.parseCallOrExpr(false)
+		if err != nil {
+			return nil, err
+		}
+		return syntax.Assign{Position: position(tkn), Name: tkn.Value, Value: value}, nil
+	}
+
+	if isName(tkn) && tkn.Value != "case" {
+		p.advance()
+		if p.startsArgument() {
+			return p.parseCallArgs(tkn)
+		}
+		p.pos--
+	}
+
+	return p.parseExpr()
+}
+
+// parseCallArgs parses the arguments of a call who's name has already been
+// consumed.
+func (p *parser) parseCallArgs(name Token) (syntax.Expr, error) {
+	call := syntax.Call{
+		Position: position(name),
+		Name:     name.Value,
+		Named:    make(map[string]syntax.Expr),
+	}
+
+	// The arguments of the transforms taking expressions may themselves be
+	// calls, such as the "sum x" of `derive s = sum x`, which take the rest of
+	// the arguments
+	parseArg := p.parseExpr
+	if expressionTransforms[name.Value] {
+		parseArg = func() (syntax.Expr, error) {
+			return p.parseCallOrExpr(false)
+		}
+	}
+
+	for p.startsArgument() {
+		tkn := p.peek(0)
+		next := p.peek(1)
+
+		if isName(tkn) && next.Type == TokenTypeOperator && next.Value == ":" {
+			// Named argument
+			p.advance()
+			p.advance()
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.Named[tkn.Value] = value
+			continue
+		}
+
+		if isName(tkn) && next.Type == TokenTypeOperator && next.Value == "=" {
+			// Assignment
+			p.advance()
+			p.advance()
+			value, err := parseArg()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, syntax.Assign{Position: position(tkn), Name: tkn.Value, Value: value})
+			continue
+		}
+
+		arg, err := parseArg()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+
+	return call, nil
+}
+
+// expressionTransforms holds the transforms which take a single expression,
+// rather than relations or nested pipelines
+var expressionTransforms = map[string]bool{
+	"aggregate": true,
+	"derive":    true,
+	"filter":    true,
+	"select":    true,
+	"sort":      true,
+	"take":      true,
+}
+
+// binaryLevels holds the binary operators by precedence, lowest first.
+var binaryLevels = [][]string{
+	{"or"},
+	{"and"},
+	{"==", "!=", ">", ">=", "<", "<=", "~="},
+	{"??"},
+	{".."},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseExpr() (syntax.Expr, error) {
+	return p.parseBinary(0)
+}
+
+// parseBinary parses binary operations at the given precedence level
+func (p *parser) parseBinary(level int) (syntax.Expr, error) {
+	if level >= len(binaryLevels) {
+		return p.parseUnary()
+	}
+
+	ops := binaryLevels[level]
+	if ops[0] == ".." {
+		return p.parseRange(level)
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tkn := p.peek(0)
+		if tkn.Type != TokenTypeOperator || !containsString(ops, tkn.Value) {
+			return left, nil
+		}
+
+		// An attached prefix operator begins the next argument of a call
+		if (tkn.Value == "-" || tkn.Value == "+") && p.startsArgument() {
+			return left, nil
+		}
+
+		p.advance()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = syntax.Binary{Position: left.Pos(), Op: tkn.Value, Left: left, Right: right}
+	}
+}
+
+// parseRange parses "start..end" where either side may be omitted
+func (p *parser) parseRange(level int) (syntax.Expr, error) {
+	tkn := p.peek(0)
+	if p.isOp("..") {
+		p.advance()
+		end, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		return syntax.Range{Position: position(tkn), End: end}, nil
+	}
+
+	start, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("..") {
+		return start, nil
+	}
+	p.advance()
+
+	rng := syntax.Range{Position: start.Pos(), Start: start}
+	if p.startsOperand() {
+		if rng.End, err = p.parseBinary(level + 1); err != nil {
+			return nil, err
+		}
+	}
+	return rng, nil
+}
+
+// startsOperand returns true if the current token can begin an operand
+func (p *parser) startsOperand() bool {
+	tkn := p.peek(0)
+	switch tkn.Type {
+	case TokenTypeGeneric, TokenTypeString, TokenTypeFString, TokenTypeSString,
+		TokenTypeNumber, TokenTypeDate:
+		return true
+	case TokenTypeKeyword:
+		return true
+	case TokenTypeOperator:
+		return tkn.Value == "(" || tkn.Value == "[" || tkn.Value == "-" || tkn.Value == "+" || tkn.Value == "!"
+	}
+	return false
+}
+
+func (p *parser) parseUnary() (syntax.Expr, error) {
+	tkn := p.peek(0)
+	if tkn.Type == TokenTypeOperator && (tkn.Value == "-" || tkn.Value == "+" || tkn.Value == "!" || tkn.Value == "==") {
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return syntax.Unary{Position: position(tkn), Op: tkn.Value, Operand: operand}, nil
+	}
+
+	return p.parseTerm()
+}
+
+// parseTerm parses a single operand, such as a literal, name, list, or a
+// parenthesised pipeline.
+func (p *parser) parseTerm() (syntax.Expr, error) {
+	tkn := p.advance()
+	pos := position(tkn)
+
+	switch tkn.Type {
+	case TokenTypeNumber:
+		return parseNumber(tkn)
+
+	case TokenTypeString:
+		return syntax.Literal{Position: pos, Type: syntax.TypeString, Value: tkn.Value}, nil
+
+	case TokenTypeDate:
+		typ := syntax.TypeDate
+		if strings.Contains(tkn.Value, "T") {
+			typ = syntax.TypeTimestamp
+		} else if len(tkn.Value) > 2 && tkn.Value[2] == ':' {
+			typ = syntax.TypeTime
+		}
+		return syntax.Literal{Position: pos, Type: typ, Value: tkn.Value}, nil
+
+	case TokenTypeSString, TokenTypeFString:
+		return parseInterpolation(tkn)
+
+	case TokenTypeGeneric, TokenTypeKeyword:
+		switch tkn.Value {
+		case "true", "false":
+			return syntax.Literal{Position: pos, Type: syntax.TypeBoolean, Value: tkn.Value}, nil
+		case "null":
+			return syntax.Literal{Position: pos, Type: syntax.TypeUnknown, Value: tkn.Value}, nil
+		case "case":
+			return p.parseCase(tkn)
+		}
+		return syntax.Ident{Position: pos, Name: tkn.Value}, nil
+
+	case TokenTypeOperator:
+		switch tkn.Value {
+		case "[":
+			return p.parseList(tkn)
+		case "(":
+			pipeline, err := p.parsePipeline()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			if len(pipeline.Stages) == 0 {
+				return nil, p.errorf(tkn, "empty parenthesis")
+			}
+			if len(pipeline.Stages) == 1 {
+				return pipeline.Stages[0], nil
+			}
+			pipeline.Position = pos
+			return *pipeline, nil
+		}
+	}
+
+	return nil, p.errorf(tkn, "unexpected %s", describe(tkn))
+}
+
+// parseCase parses the bracketed "condition => value" arms of a case
+// expression, who's keyword has already been consumed.
+func (p *parser) parseCase(keyword Token) (syntax.Expr, error) {
+	open := p.peek(0)
+	if err := p.expectOp("["); err != nil {
+		return nil, err
+	}
+	expr := syntax.Case{Position: position(keyword)}
+
+	for !p.isOp("]") {
+		if p.done() {
+			return nil, p.errorf(open, "case is not closed")
+		}
+
+		cond, err := p.parseCallOrExpr(false)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp("=>"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseCallOrExpr(false)
+		if err != nil {
+			return nil, err
+		}
+		expr.Arms = append(expr.Arms, syntax.CaseArm{Condition: cond, Value: value})
+
+		if p.isOp(",") {
+			p.advance()
+		} else if !p.isOp("]") {
+			return nil, p.errorf(p.peek(0), "expected ',' or ']' but found %s", describe(p.peek(0)))
+		}
+	}
+	p.advance()
+
+	if len(expr.Arms) == 0 {
+		return nil, p.errorf(keyword, "case has no arms")
+	}
+	return expr, nil
+}
+
+// parseList parses a list who's opening bracket has already been consumed.
+// Items are separated by commas, and a trailing comma is allowed.
+func (p *parser) parseList(open Token) (syntax.Expr, error) {
+	list := syntax.List{Position: position(open)}
+
+	for !p.isOp("]") {
+		if p.done() {
+			return nil, p.errorf(open, "list is not closed")
+		}
+
+		item, err := p.parseCallOrExpr(true)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+
+		if p.isOp(",") {
+			p.advance()
+		} else if !p.isOp("]") {
+			return nil, p.errorf(p.peek(0), "expected ',' or ']' but found %s", describe(p.peek(0)))
+		}
+	}
+	p.advance()
+
+	return list, nil
+}
+
+// parseNumber converts a number token into either a Literal, or an Interval if
+// a unit is attached.
+func parseNumber(tkn Token) (syntax.Expr, error) {
+	pos := position(tkn)
+
+	end := strings.IndexFunc(tkn.Value, func(r rune) bool {
+		return (r >= 'a' && r <= 'z' && r != 'e') || (r >= 'A' && r <= 'Z' && r != 'E')
+	})
+	if end >= 0 {
+		value, unit := tkn.Value[:end], tkn.Value[end:]
+		if !intervalUnits[unit] {
+			return nil, SyntaxError{tkn.Line, tkn.Character, fmt.Sprintf("unknown interval unit '%s'", unit)}
+		}
+		return syntax.Interval{Position: pos, Value: value, Unit: unit}, nil
+	}
+
+	typ := syntax.TypeInteger
+	if strings.ContainsAny(tkn.Value, ".eE") {
+		typ = syntax.TypeFloat
+	}
+	return syntax.Literal{Position: pos, Type: typ, Value: tkn.Value}, nil
+}
+
+// parseInterpolation splits an s-string or f-string into it's text and
+// expression parts. Double curly-braces escape a literal brace.
+func parseInterpolation(tkn Token) (syntax.Expr, error) {
+	kind := byte('s')
+	if tkn.Type == TokenTypeFString {
+		kind = 'f'
+	}
+	interp := syntax.Interpolation{Position: position(tkn), Kind: kind}
+
+	var text strings.Builder
+	src := tkn.Value
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if c == '{' && i+1 < len(src) && src[i+1] == '{' {
+			text.WriteByte('{')
+			i++
+			continue
+		}
+		if c == '}' && i+1 < len(src) && src[i+1] == '}' {
+			text.WriteByte('}')
+			i++
+			continue
+		}
+		if c != '{' {
+			text.WriteByte(c)
+			continue
+		}
+
+		end := strings.IndexByte(src[i:], '}')
+		if end < 0 {
+			return nil, SyntaxError{tkn.Line, tkn.Character, "interpolation is missing a closing '}'"}
+		}
+
+		expr, err := parseInnerExpr(src[i+1:i+end], tkn)
+		if err != nil {
+			return nil, err
+		}
+
+		if text.Len() > 0 {
+			interp.Parts = append(interp.Parts, syntax.InterpolationPart{Text: text.String()})
+			text.Reset()
+		}
+		interp.Parts = append(interp.Parts, syntax.InterpolationPart{Expr: expr})
+		i += end
+	}
+
+	if text.Len() > 0 {
+		interp.Parts = append(interp.Parts, syntax.InterpolationPart{Text: text.String()})
+	}
+
+	return interp, nil
+}
+
+// parseInnerExpr parses an expression found within an interpolated string.
+// Positions are reported relative to the string token.
+func parseInnerExpr(source string, outer Token) (syntax.Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, SyntaxError{outer.Line, outer.Character, err.Error()}
+	}
+	for i := range tokens {
+		tokens[i].Line = outer.Line
+		tokens[i].Character = outer.Character
+	}
+
+	inner := &parser{tokens: tokens}
+	expr, err := inner.parseCallOrExpr(false)
+	if err != nil {
+		return nil, err
+	}
+	if !inner.done() {
+		return nil, inner.errorf(inner.peek(0), "unexpected %s in interpolation", describe(inner.peek(0)))
+	}
+	return expr, nil
+}
+
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`prql dialect:postgres version:1
+
+from e = employees
+filter salary > 1000 and title == "Engineer"
+group [title, dept] (
+	sort -salary
+	take 1
+)
+derive gross = salary + payroll_tax * 2
+derive tier = case [gross > 1000 => "high", true => "low"]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if query.Header.Dialect != syntax.DialectPostgres {
+		t.Errorf("expected dialect postgres, received %s", query.Header.Dialect.String())
+	}
+	if ver, ok := query.Header.Version.Get(); !ok || *ver != 1 {
+		t.Error("expected version 1")
+	}
+
+	expected := []string{
+		`from e = employees`,
+		`filter ((salary > 1000) and (title == "Engineer"))`,
+		`group [title, dept] (sort -salary | take 1)`,
+		`derive gross = (salary + (payroll_tax * 2))`,
+		`derive tier = case [(gross > 1000) => "high", true => "low"]`,
+	}
+	if len(query.Main.Stages) != len(expected) {
+		t.Fatalf("expected %d stages, received %d", len(expected), len(query.Main.Stages))
+	}
+	for i, stage := range query.Main.Stages {
+		if stage.String() != expected[i] {
+			t.Errorf("stage %d expected `%s`, received `%s`", i, expected[i], stage.String())
+		}
+	}
+}
+
+func TestParseTables(t *testing.T) {
+	query, err := Parse(`
+let top = (from customers | sort -total | take 10)
+table recent = from orders
+
+from recent
+join top [==id]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"top":    "(from customers | sort -total | take 10)",
+		"recent": "(from orders)",
+	}
+	if len(query.Tables) != len(expected) {
+		t.Fatalf("expected %d tables, received %d", len(expected), len(query.Tables))
+	}
+	for _, table := range query.Tables {
+		if table.Pipeline.String() != expected[table.Name] {
+			t.Errorf("table %s expected `%s`, received `%s`", table.Name, expected[table.Name], table.Pipeline.String())
+		}
+	}
+	if len(query.Main.Stages) != 2 {
+		t.Errorf("expected 2 stages, received %d", len(query.Main.Stages))
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	query, err := Parse(`
+func pi -> 3.14
+func interpolate low:0 high:(pi) val -> (val - low) / (high - low)
+
+from readings
+derive [scaled = (interpolate high:10 value)]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(query.Functions) != 2 {
+		t.Fatalf("expected 2 functions, received %d", len(query.Functions))
+	}
+
+	fn := query.Functions[1]
+	if fn.Name != "interpolate" || len(fn.Named) != 2 || len(fn.Positional) != 1 {
+		t.Fatalf("unexpected function %+v", fn)
+	}
+	if fn.Named[0].Name != "low" || fn.Named[0].Default.String() != "0" || fn.Named[1].Name != "high" {
+		t.Errorf("unexpected named parameters %+v", fn.Named)
+	}
+	if fn.Positional[0] != "val" {
+		t.Errorf("unexpected positional parameters %v", fn.Positional)
+	}
+	if fn.Body.String() != "((val - low) / (high - low))" {
+		t.Errorf("unexpected body `%s`", fn.Body.String())
+	}
+}
+
+func TestParseRegex(t *testing.T) {
+	query, err := Parse(`from users | filter email ~= "@example\\.com$" and name != ""`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `filter ((email ~= "@example\\.com$") and (name != ""))`
+	if stage := query.Main.Stages[1].String(); stage != expected {
+		t.Errorf("expected `%s`, received `%s`", expected, stage)
+	}
+}
+
+func TestParseTransformCalls(t *testing.T) {
+	query, err := Parse(`from t
+derive s = sum x
+aggregate total = sum x
+filter text.starts_with name "A"
+join c = countries [id]
+take`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The number of positional arguments of each transform
+	expected := []int{1, 1, 1, 1, 2, 0}
+	if len(query.Main.Stages) != len(expected) {
+		t.Fatalf("expected %d stages, received %d", len(expected), len(query.Main.Stages))
+	}
+	for i, stage := range query.Main.Stages {
+		call, ok := stage.(syntax.Call)
+		if !ok {
+			t.Errorf("stage %d expected a call, received `%s`", i, stage.String())
+		} else if len(call.Args) != expected[i] {
+			t.Errorf("stage %d expected %d arguments, received %d", i, expected[i], len(call.Args))
+		}
+	}
+
+	assign, ok := query.Main.Stages[1].(syntax.Call).Args[0].(syntax.Assign)
+	if !ok {
+		t.Fatal("expected 'derive' to assign it's argument")
+	}
+	if sum, ok := assign.Value.(syntax.Call); !ok || sum.Name != "sum" || len(sum.Args) != 1 {
+		t.Errorf("expected the call `sum x`, received `%s`", assign.Value.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		`from employees | derive [a = 1`: "1:31: expected ',' or ']' but found end of input",
+		`from employees | take 1x`:       "1:23: unknown interval unit 'x'",
+		`from "employees`:                "1:6: string literal does not terminate",
+		"from `employees":                "1:6: quoted identifier does not terminate",
+		`prql target:sql`:                "1:6: unknown prql header parameter 'target'",
+		"let 1 = (from a)\nfrom a":       "1:5: expected a name after 'let' but found '1'",
+		"let a = 5\nfrom a":              "1:9: 'let' expects a pipeline but found 5",
+		"func f x x -> x\nfrom a":        "1:10: parameter 'x' is already declared",
+		"func f x y:1 -> x\nfrom a":      "1:10: named parameter 'y' must be declared before the positional parameters",
+		"from a |":                       "1:8: expected a transform after '|' but found end of input",
+		"from a | group b (take 1 |)":    "1:26: expected a transform after '|' but found ')'",
+	}
+
+	for source, message := range tests {
+		_, err := Parse(source)
+		if err == nil {
+			t.Errorf("expected error for `%s`", source)
+		} else if len(err.Error()) < len(message) || err.Error()[:len(message)] != message {
+			t.Errorf("expected error `%s`, received `%s`", message, err.Error())
+		}
+	}
+}
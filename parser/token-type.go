@@ -35,6 +35,14 @@ const (
 
 	// TokenTypeSString represents the entire contents of a s-string.
 	TokenTypeSString
+
+	// TokenTypeNumber represents a numeric literal, which may be followed
+	// directly by an interval unit such as "7days".
+	TokenTypeNumber
+
+	// TokenTypeDate represents a date, time, or timestamp literal. The leading
+	// "@" is not included in the value.
+	TokenTypeDate
 )
 
 func (t TokenType) String() string {
@@ -55,6 +63,10 @@ func (t TokenType) String() string {
 		return "F-STRING"
 	case TokenTypeSString:
 		return "S-STRING"
+	case TokenTypeNumber:
+		return "NUMBER"
+	case TokenTypeDate:
+		return "DATE"
 	default:
 		return "UNKNOWN"
 	}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 )
@@ -24,15 +25,58 @@ type Token struct {
 
 type Tokens []Token
 
-type tokenMode byte
+// keywords holds the identifiers which are tokenized as TokenTypeKeyword
+// rather than TokenTypeGeneric. Keywords may still be used as references by
+// the parser where the context allows it.
+var keywords = map[string]bool{
+	"prql":      true,
+	"func":      true,
+	"let":       true,
+	"table":     true,
+	"case":      true,
+	"aggregate": true,
+	"append":    true,
+	"derive":    true,
+	"filter":    true,
+	"from":      true,
+	"group":     true,
+	"intersect": true,
+	"join":      true,
+	"loop":      true,
+	"remove":    true,
+	"select":    true,
+	"sort":      true,
+	"take":      true,
+	"union":     true,
+	"window":    true,
+}
 
-const (
-	tokenModeStart tokenMode = iota
-	tokenModeKeyword
-	tokenModeGeneric
-	tokenModeComment
-	tokenModeString
-)
+// wordOperators holds the identifiers which are tokenized as operators
+var wordOperators = map[string]bool{
+	"and": true,
+	"or":  true,
+}
+
+// twoCharOperators holds the operators made of two characters. These are
+// checked before any single character operator.
+var twoCharOperators = []string{"==", "!=", ">=", "<=", "->", "=>", "..", "??", "~="}
+
+// tokenizer holds the state while reading an input source
+type tokenizer struct {
+	src    []rune
+	pos    int
+	line   uint
+	char   uint
+	tokens Tokens
+
+	// brackets holds the stack of currently open "[" and "(" characters. New
+	// lines within a "[" list are whitespace, everywhere else they are pipes.
+	brackets []rune
+
+	// pipe holds the position of a "|" character which is not yet followed by
+	// another token, as it must be followed by a transform
+	pipe *Token
+}
 
 // tokenize reads an input string, and tokenize's it to PRQL standards.
 //
@@ -40,226 +84,322 @@ const (
 //
 // Additionally, since the pipeline operator "|" and newline are synonymous they
 // are normalized to the pipeline operator. This does not effect the tokens
-// position within the source text.
-func tokenize(source string) Tokens {
-	tokens := make(Tokens, 0)
-
-	mode := tokenModeStart
-	var numLine uint = 1
-	var numChar uint = 0
-	var tknStartChar uint = 0
-	hadPipeline := false
-	hasContent := false
-	inBlock := false
-	lastChar := '\n'
-	var tkn strings.Builder
+// position within the source text. Repeated pipes are collapsed into one.
+func tokenize(source string) (Tokens, error) {
+	t := &tokenizer{
+		src:    []rune(source),
+		line:   1,
+		char:   1,
+		tokens: make(Tokens, 0),
+	}
 
-	var typ TokenType = TokenTypeUnknown
-	var lastTyp TokenType = TokenTypeUnknown
-
-	var strChar rune
-	strBlockLen := 1
-	strBlockRem := 1
-
-	// Local lambda to push the current token and reset the state
-	pushToken := func() {
-		if tkn.Len() > 0 {
-			tokens = append(tokens, Token{
-				Type:      typ,
-				Value:     tkn.String(),
-				Line:      numLine,
-				Character: tknStartChar,
-			})
-
-			lastTyp = typ
-			typ = TokenTypeUnknown
-			tkn.Reset()
+	for t.pos < len(t.src) {
+		if err := t.next(); err != nil {
+			return nil, err
 		}
+	}
 
-		hasContent = false
+	if t.pipe != nil {
+		return nil, t.errorf(t.pipe.Line, t.pipe.Character, "expected a transform after '|' but found end of input")
 	}
 
-	pushPipeline := func() {
-		if hadPipeline && lastTyp != TokenTypePipe {
-			tokens = append(tokens, Token{
-				Type:      TokenTypePipe,
-				Value:     "|",
-				Line:      numLine,
-				Character: numChar,
-			})
-
-			hadPipeline = false
-			lastTyp = TokenTypePipe
-		}
+	// Drop any trailing pipe
+	if n := len(t.tokens); n > 0 && t.tokens[n-1].Type == TokenTypePipe {
+		t.tokens = t.tokens[:n-1]
 	}
 
-	for _, char := range source {
-		numChar++
-		switch mode {
-		case tokenModeStart:
-			if !unicode.IsSpace(char) {
-				tknStartChar = numChar
-
-				// Non-space character declares token has started
-				// Ignore any whitespace before this
-				if char == '|' {
-					pushPipeline()
-				} else if char == '#' {
-					// Start comment
-					mode = tokenModeComment
-					typ = TokenTypeComment
-				} else if char == '\'' || char == '"' {
-					// Start string literal
-					strChar = char
-					mode = tokenModeString
-					typ = TokenTypeString
-				} else if strings.ContainsRune("[]()=", char) {
-					// Insert operator for block start
-					tokens = append(tokens, Token{
-						Type:      TokenTypeOperator,
-						Value:     string(char),
-						Line:      numLine,
-						Character: numChar,
-					})
-					lastTyp = TokenTypeOperator
-				} else {
-					if lastTyp == TokenTypeKeyword || lastTyp == TokenTypeGeneric {
-						mode = tokenModeGeneric
-						typ = TokenTypeUnknown
-					} else {
-						mode = tokenModeKeyword
-						typ = TokenTypeKeyword
-					}
-					tkn.WriteRune(char)
-				}
-			}
+	return t.tokens, nil
+}
 
-		case tokenModeComment:
-			// Comments run until the end of the line
-			if char == '\n' {
-				// End this comment line
-				if hasContent {
-					pushToken()
-				}
+// peek returns the rune at the given offset from the current position, or 0
+// if that is past the end of the source.
+func (t *tokenizer) peek(offset int) rune {
+	if t.pos+offset < len(t.src) {
+		return t.src[t.pos+offset]
+	}
+	return 0
+}
+
+// advance moves the position forward by one rune, tracking lines.
+func (t *tokenizer) advance() rune {
+	char := t.src[t.pos]
+	t.pos++
+	if char == '\n' {
+		t.line++
+		t.char = 1
+	} else {
+		t.char++
+	}
+	return char
+}
+
+// push appends a new token starting at the given line and character.
+func (t *tokenizer) push(typ TokenType, value string, line, char uint) {
+	if typ != TokenTypePipe {
+		t.pipe = nil
+	}
+	t.tokens = append(t.tokens, Token{
+		Type:      typ,
+		Value:     value,
+		Line:      line,
+		Character: char,
+	})
+}
+
+// pushPipe appends a pipe token, unless the last token was already a pipe or
+// there are no tokens yet.
+func (t *tokenizer) pushPipe(line, char uint) {
+	n := len(t.tokens)
+	if n == 0 || t.tokens[n-1].Type == TokenTypePipe {
+		return
+	}
+	if last := t.tokens[n-1]; last.Type == TokenTypeOperator && last.Value == "(" {
+		return
+	}
+	t.push(TokenTypePipe, "|", line, char)
+}
+
+func (t *tokenizer) errorf(line, char uint, format string, args ...any) error {
+	return SyntaxError{line, char, fmt.Sprintf(format, args...)}
+}
+
+func isIdentStart(char rune) bool {
+	return char == '_' || unicode.IsLetter(char)
+}
+
+func isIdentChar(char rune) bool {
+	return char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char)
+}
+
+// next reads the next token from the source.
+func (t *tokenizer) next() error {
+	line, char := t.line, t.char
+	c := t.peek(0)
+
+	switch {
+	case c == '\n':
+		t.advance()
+		if len(t.brackets) == 0 || t.brackets[len(t.brackets)-1] == '(' {
+			t.pushPipe(line, char)
+		}
+
+	case unicode.IsSpace(c):
+		t.advance()
+
+	case c == '#':
+		// Comments run until the end of the line
+		for t.pos < len(t.src) && t.peek(0) != '\n' {
+			t.advance()
+		}
+
+	case c == '|':
+		t.advance()
+		t.pushPipe(line, char)
+		if len(t.tokens) > 0 {
+			t.pipe = &Token{Type: TokenTypePipe, Value: "|", Line: line, Character: char}
+		}
+
+	case (c == 's' || c == 'f') && (t.peek(1) == '"' || t.peek(1) == '\''):
+		t.advance()
+		str, err := t.readString()
+		if err != nil {
+			return err
+		}
+		if c == 's' {
+			t.push(TokenTypeSString, str, line, char)
+		} else {
+			t.push(TokenTypeFString, str, line, char)
+		}
 
-				// Reset back to starting line
-				mode = tokenModeStart
-				hadPipeline = false
-				hasContent = false
+	case c == '"' || c == '\'':
+		str, err := t.readString()
+		if err != nil {
+			return err
+		}
+		t.push(TokenTypeString, str, line, char)
+
+	case c == '`':
+		// Quoted identifiers keep any characters, such as the dashes of a
+		// BigQuery project
+		t.advance()
+		var tkn strings.Builder
+		for t.pos < len(t.src) && t.peek(0) != '`' && t.peek(0) != '\n' {
+			tkn.WriteRune(t.advance())
+		}
+		if t.peek(0) != '`' || tkn.Len() == 0 {
+			return t.errorf(line, char, "quoted identifier does not terminate with the character `")
+		}
+		t.advance()
+		t.push(TokenTypeGeneric, tkn.String(), line, char)
+
+	case c == '@':
+		t.advance()
+		var tkn strings.Builder
+		for t.pos < len(t.src) {
+			p := t.peek(0)
+			if unicode.IsDigit(p) || p == '-' || p == ':' || p == 'T' || p == 'Z' || p == '+' ||
+				(p == '.' && unicode.IsDigit(t.peek(1))) {
+				tkn.WriteRune(t.advance())
 			} else {
-				if hasContent {
-					tkn.WriteRune(char)
-				} else if !unicode.IsSpace(char) {
-					tkn.WriteRune(char)
-					hasContent = true
-				}
+				break
 			}
+		}
+		if tkn.Len() == 0 {
+			return t.errorf(line, char, "expected date or time after '@'")
+		}
+		t.push(TokenTypeDate, tkn.String(), line, char)
 
-		case tokenModeString:
-
-			if char == strChar {
-				// Strings end when the starting character ends the same number of times
-				if !hasContent {
-					strBlockLen++
-				} else {
-					// Check if this is a "block" string
-					if inBlock {
-						// Deduct from chars remaining for end of string
-						strBlockRem--
-						if strBlockRem == 0 {
-							// End met, push the token
-							pushToken()
-							mode = tokenModeStart
-							strBlockLen = 1
-							inBlock = false
-						}
-					} else {
-						// Not a block, just push the token
-						pushToken()
-						hasContent = false
-						mode = tokenModeStart
-						strBlockLen = 1
-					}
-				}
+	case unicode.IsDigit(c):
+		t.push(TokenTypeNumber, t.readNumber(), line, char)
+
+	case isIdentStart(c):
+		var tkn strings.Builder
+		for t.pos < len(t.src) {
+			p := t.peek(0)
+			if isIdentChar(p) || (p == '.' && isIdentStart(t.peek(1))) {
+				tkn.WriteRune(t.advance())
 			} else {
-				if !hasContent {
-					hasContent = true
-
-					if strBlockLen >= 3 {
-						inBlock = true
-						strBlockRem = strBlockLen
-					}
-				} else if strBlockRem < strBlockLen {
-					// We skipped thinking it might be end of block, but it wasn't.
-					// So replace the missing chars we skipped.
-					for i := (strBlockLen - strBlockRem); i > 0; i-- {
-						tkn.WriteRune(strChar)
-					}
-					strBlockRem = strBlockLen
-				}
+				break
+			}
+		}
+
+		word := tkn.String()
+		if wordOperators[word] {
+			t.push(TokenTypeOperator, word, line, char)
+		} else if keywords[word] {
+			t.push(TokenTypeKeyword, word, line, char)
+		} else {
+			t.push(TokenTypeGeneric, word, line, char)
+		}
 
-				tkn.WriteRune(char)
+	default:
+		for _, op := range twoCharOperators {
+			if c == rune(op[0]) && t.peek(1) == rune(op[1]) {
+				t.advance()
+				t.advance()
+				t.push(TokenTypeOperator, op, line, char)
+				return nil
 			}
+		}
 
-		case tokenModeKeyword:
-			if unicode.IsSpace(char) {
-				// keywords end with whitespace
-				pushToken()
-				mode = tokenModeStart
-			} else {
-				tkn.WriteRune(char)
-				hasContent = true
-				hadPipeline = true
+		if !strings.ContainsRune("[](),:=<>+-*/%!", c) {
+			return t.errorf(line, char, "unexpected character '%c'", c)
+		}
+
+		t.advance()
+		switch c {
+		case '[', '(':
+			t.brackets = append(t.brackets, c)
+		case ']', ')':
+			if len(t.brackets) > 0 {
+				t.brackets = t.brackets[:len(t.brackets)-1]
 			}
 
-		case tokenModeGeneric:
-			if unicode.IsSpace(char) {
-				// keywords end with whitespace
-				pushToken()
-				mode = tokenModeStart
-			} else {
-				// Check for special cases
-				if char == '\'' || char == '"' {
-					// Could be F-STRING or S-STRING
-					if tkn.Len() == 2 && (lastChar == 'f' || lastChar == 's') {
-						// Start string literal
-						tkn.Reset()
-						strChar = char
-						strBlockLen = 1
-						mode = tokenModeString
-						hasContent = false
-
-						if lastChar == 'f' {
-							typ = TokenTypeFString
-						} else if lastChar == 's' {
-							typ = TokenTypeSString
-						}
-					}
-				} else {
-					typ = TokenTypeGeneric
-
-					tkn.WriteRune(char)
-					hasContent = true
-					hadPipeline = true
-				}
+			// Pipes are not allowed right before closing a block
+			if t.pipe != nil && c == ')' {
+				return t.errorf(t.pipe.Line, t.pipe.Character, "expected a transform after '|' but found ')'")
+			}
+			if n := len(t.tokens); c == ')' && n > 0 && t.tokens[n-1].Type == TokenTypePipe {
+				t.tokens = t.tokens[:n-1]
 			}
 		}
-		// END SWITCH
+		t.push(TokenTypeOperator, string(c), line, char)
+	}
+
+	return nil
+}
+
+// readNumber reads an integer or decimal number, including an optional
+// exponent. Any letters directly following the number are included so that
+// intervals such as "7days" are kept as one token.
+func (t *tokenizer) readNumber() string {
+	var tkn strings.Builder
+	for t.pos < len(t.src) {
+		p := t.peek(0)
+		if unicode.IsDigit(p) || p == '_' {
+			if p != '_' {
+				tkn.WriteRune(p)
+			}
+			t.advance()
+		} else if p == '.' && unicode.IsDigit(t.peek(1)) && !strings.Contains(tkn.String(), ".") {
+			tkn.WriteRune(t.advance())
+		} else if (p == 'e' || p == 'E') && (unicode.IsDigit(t.peek(1)) ||
+			((t.peek(1) == '-' || t.peek(1) == '+') && unicode.IsDigit(t.peek(2)))) {
+			tkn.WriteRune(t.advance())
+			tkn.WriteRune(t.advance())
+		} else if unicode.IsLetter(p) {
+			tkn.WriteRune(t.advance())
+		} else {
+			break
+		}
+	}
+	return tkn.String()
+}
+
+// readString reads a quoted string starting at the current position. Strings
+// that open with three or more quote characters are "block" strings, and end
+// only when the same number of quote characters is met again. Within normal
+// strings a backslash escapes the following character.
+func (t *tokenizer) readString() (string, error) {
+	line, char := t.line, t.char
+	quote := t.peek(0)
+
+	blockLen := 0
+	for t.peek(0) == quote {
+		t.advance()
+		blockLen++
+	}
+
+	// Two quotes is an empty string
+	if blockLen == 2 {
+		return "", nil
+	}
 
-		if char == '\n' {
-			if !inBlock {
-				if hadPipeline {
-					pushToken()
-					hasContent = false
+	var tkn strings.Builder
+	for t.pos < len(t.src) {
+		c := t.peek(0)
+
+		if blockLen == 1 {
+			if c == '\\' && t.pos+1 < len(t.src) {
+				t.advance()
+				switch esc := t.advance(); esc {
+				case 'n':
+					tkn.WriteRune('\n')
+				case 't':
+					tkn.WriteRune('\t')
+				default:
+					tkn.WriteRune(esc)
 				}
-				pushPipeline()
+				continue
+			}
+			if c == quote {
+				t.advance()
+				return tkn.String(), nil
 			}
+			if c == '\n' {
+				break
+			}
+			tkn.WriteRune(t.advance())
+			continue
+		}
 
-			numLine++
-			numChar = 0
+		// Check for the end of a block string
+		run := 0
+		for t.peek(run) == quote {
+			run++
+		}
+		if run >= blockLen {
+			// Any extra quotes belong to the contents
+			for i := 0; i < run-blockLen; i++ {
+				tkn.WriteRune(t.advance())
+			}
+			for i := 0; i < blockLen; i++ {
+				t.advance()
+			}
+			return tkn.String(), nil
 		}
-		lastChar = char
+		tkn.WriteRune(t.advance())
 	}
 
-	return tokens
+	return "", t.errorf(line, char, "string literal does not terminate with the same character %c", quote)
 }
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"testing"
 )
 
@@ -32,11 +31,54 @@ func TestTokenizer(t *testing.T) {
 		db_version = s''''version()'''',    # An S-string, which transpiles directly into SQL
 	]`
 
-	tokens := tokenize(input)
-	fmt.Printf("Tokenized, generated %d tokens\n", len(tokens))
+	tokens, err := tokenize(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
 	for ind, tkn := range tokens {
-		fmt.Printf("[%d] %d:%d %s `%s`\n", ind, tkn.Line, tkn.Character, tkn.Type.String(), tkn.Value)
+		if tkn.Type == TokenTypeUnknown {
+			t.Errorf("[%d] %d:%d unknown token `%s`", ind, tkn.Line, tkn.Character, tkn.Value)
+		}
 	}
 
-	t.Fail()
+	expected := []Token{
+		{TokenTypeKeyword, "from", 1, 1},
+		{TokenTypeGeneric, "emp", 1, 6},
+		{TokenTypeOperator, "=", 1, 10},
+		{TokenTypeGeneric, "employees", 1, 12},
+		{TokenTypePipe, "|", 1, 21},
+		{TokenTypeKeyword, "filter", 2, 2},
+		{TokenTypeGeneric, "country_code", 2, 9},
+		{TokenTypeOperator, "==", 2, 22},
+		{TokenTypeString, "USA", 2, 25},
+		{TokenTypePipe, "|", 2, 76},
+		{TokenTypeKeyword, "derive", 3, 2},
+		{TokenTypeOperator, "[", 3, 9},
+		{TokenTypeGeneric, "gross_salary", 4, 3},
+		{TokenTypeOperator, "=", 4, 16},
+		{TokenTypeSString, "salary + payroll_tax", 4, 18},
+		{TokenTypeOperator, ",", 4, 41},
+	}
+	for ind, exp := range expected {
+		if ind >= len(tokens) {
+			t.Fatalf("expected at least %d tokens, received %d", len(expected), len(tokens))
+		}
+		if tokens[ind] != exp {
+			t.Errorf("[%d] expected %d:%d %s `%s`, received %d:%d %s `%s`", ind,
+				exp.Line, exp.Character, exp.Type.String(), exp.Value,
+				tokens[ind].Line, tokens[ind].Character, tokens[ind].Type.String(), tokens[ind].Value)
+		}
+	}
+
+	last := tokens[len(tokens)-1]
+	if last.Type != TokenTypeOperator || last.Value != "]" {
+		t.Errorf("expected last token to close the list, received `%s`", last.Value)
+	}
+	for _, tkn := range tokens {
+		if tkn.Type == TokenTypeSString && tkn.Value == "version()" {
+			return
+		}
+	}
+	t.Error("expected the block s-string to be read as `version()`")
 }
@@ -1,9 +1,61 @@
 package prql
 
+import (
+	"errors"
+
+	"github.com/chris-pikul/go-prql/compiler"
+	"github.com/chris-pikul/go-prql/parser"
+	"github.com/chris-pikul/go-prql/syntax"
+)
+
+// Options holds the settings used when compiling a query.
+type Options = compiler.Options
+
+// Warning is a part of a query which compiled, but is likely a mistake.
+type Warning = compiler.Warning
+
+// Dialect generates the SQL which differs between databases.
+type Dialect = compiler.Dialect
+
+// RegisterDialect adds a Dialect of the given name, which queries may then
+// target with the "prql" header such as "prql dialect:trino".
+func RegisterDialect(name string, impl Dialect) (syntax.Dialect, error) {
+	return compiler.RegisterDialect(name, impl)
+}
+
 // Compile takes an incoming PRQL query (string) and returns the SQL standard
 // equivelent (string), or an error if one occured. In the event of an error,
 // the string returned will be empty. The error type is a custom type wrapping
 // the go standard error as "prql.Error".
 func Compile(source string) (string, *Error) {
-	return "", nil
+	return CompileWithOptions(source, Options{})
+}
+
+// CompileWithOptions is the same as Compile, with the given Options changing
+// the settings used to generate the SQL.
+func CompileWithOptions(source string, opts Options) (string, *Error) {
+	sql, _, err := CompileWithWarnings(source, opts)
+	return sql, err
+}
+
+// CompileWithWarnings is the same as CompileWithOptions, also returning the
+// warnings for any parts of the query which are likely mistakes.
+func CompileWithWarnings(source string, opts Options) (string, []Warning, *Error) {
+	query, err := parser.Parse(source)
+	if err != nil {
+		prqlErr := NewError(ErrorTypeSyntax, err)
+		return "", nil, &prqlErr
+	}
+
+	sql, warnings, err := compiler.CompileWithWarnings(query, opts)
+	if err != nil {
+		errType := ErrorTypeCompile
+		if errors.As(err, &compiler.UnsupportedError{}) {
+			errType = ErrorTypeUnsupported
+		}
+		prqlErr := NewError(errType, err)
+		return "", nil, &prqlErr
+	}
+
+	return sql, warnings, nil
 }
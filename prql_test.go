@@ -0,0 +1,34 @@
+package prql_test
+
+import (
+	"testing"
+
+	"github.com/chris-pikul/go-prql"
+)
+
+func TestCompile(t *testing.T) {
+	sql, err := prql.Compile("from employees\ntake 10")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.String())
+	}
+	if sql != "SELECT *\nFROM employees\nLIMIT 10" {
+		t.Errorf("unexpected SQL %q", sql)
+	}
+}
+
+func TestCompileErrorTypes(t *testing.T) {
+	tests := map[string]prql.ErrorType{
+		`from "employees`:                                   prql.ErrorTypeSyntax,
+		"from employees\nexplode x":                         prql.ErrorTypeCompile,
+		"prql dialect:sqlite\nfrom a\naggregate [stddev x]": prql.ErrorTypeUnsupported,
+	}
+
+	for source, errType := range tests {
+		_, err := prql.Compile(source)
+		if err == nil {
+			t.Errorf("expected %s error for %q", errType.String(), source)
+		} else if err.Type != errType {
+			t.Errorf("expected %s error for %q, received %s", errType.String(), source, err.String())
+		}
+	}
+}
@@ -2,12 +2,14 @@ package syntax
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/chris-pikul/go-prql/utils"
 )
 
 // Dialect is a byte enum representing the accepted dialects. This is declared
-// by the top-level "prql" expression.
+// by the top-level "prql" expression. Values beyond those declared here are
+// added with RegisterDialect.
 type Dialect byte
 
 const (
@@ -40,9 +42,35 @@ var dialectStringMap = map[Dialect]string{
 // holds string -> Dialect mapping
 var dialectDialectMap = utils.InvertMap(dialectStringMap)
 
+// guards the mappings of registered dialects
+var dialectLock sync.RWMutex
+
+// RegisterDialect adds a Dialect of the given name, so that it is accepted by
+// UnmarshalText and the "prql" header. Returns an error if the name is
+// already used, or no more dialects can be added.
+func RegisterDialect(name string) (Dialect, error) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+
+	if _, exists := dialectDialectMap[name]; exists {
+		return DialectGeneric, fmt.Errorf("dialect '%s' is already registered", name)
+	}
+	if len(dialectStringMap) > 255 {
+		return DialectGeneric, fmt.Errorf("too many dialects to register '%s'", name)
+	}
+
+	dial := Dialect(len(dialectStringMap))
+	dialectStringMap[dial] = name
+	dialectDialectMap[name] = dial
+	return dial, nil
+}
+
 // String returns the string representation of the underlying Dialect enum. If
 // invalid, defaults to returning "generic".
 func (d Dialect) String() string {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+
 	if str, ok := dialectStringMap[d]; ok {
 		return str
 	}
@@ -56,9 +84,13 @@ func (d Dialect) String() string {
 //
 // Important: this is CASE-SENSITIVE.
 func (d *Dialect) UnmarshalText(text []byte) error {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+
 	str := string(text)
 	if dial, ok := dialectDialectMap[str]; ok {
 		*d = dial
+		return nil
 	}
 	return fmt.Errorf("invalid Dialect '%s'", str)
 }
@@ -0,0 +1,272 @@
+package syntax
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Position holds the location of a node within the PRQL source. Both values
+// are 1-based, and a zero-value Position means the location is unknown.
+type Position struct {
+	Line      uint
+	Character uint
+}
+
+// Pos returns the Position itself, allowing it to be embedded into the
+// expression nodes to satisfy the Expr interface.
+func (p Position) Pos() Position {
+	return p
+}
+
+// Before returns true if this position comes before the other within the
+// source.
+func (p Position) Before(other Position) bool {
+	return p.Line < other.Line || (p.Line == other.Line && p.Character < other.Character)
+}
+
+// String returns the position formatted as "{line}:{character}".
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Character)
+}
+
+// Expr is any node within a PRQL expression or pipeline.
+//
+// String returns the PRQL source representation of the node.
+type Expr interface {
+	Pos() Position
+	String() string
+}
+
+// Ident is a reference to a column, table, relation, or function by name. The
+// name may contain dots to qualify it, such as "employees.salary".
+type Ident struct {
+	Position
+	Name string
+}
+
+// String returns the PRQL expression for this Ident. Names with characters
+// other than letters, digits, underscores and dots are quoted with backticks.
+func (i Ident) String() string {
+	for _, char := range i.Name {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' && char != '.' {
+			return "`" + i.Name + "`"
+		}
+	}
+	return i.Name
+}
+
+// Parts splits the name on the dot separator, returning the qualifiers and the
+// final name in order.
+func (i Ident) Parts() []string {
+	return strings.Split(i.Name, ".")
+}
+
+// Literal is a constant value. The Value is kept as it appeared in the source,
+// without any quotes for strings, and without the "@" for temporal values.
+//
+// A "null" literal has a Type of TypeUnknown.
+type Literal struct {
+	Position
+	Type  Type
+	Value string
+}
+
+// String returns the PRQL expression for this Literal.
+func (l Literal) String() string {
+	switch l.Type {
+	case TypeString:
+		return fmt.Sprintf("%q", l.Value)
+	case TypeDate, TypeTime, TypeTimestamp:
+		return "@" + l.Value
+	}
+	return l.Value
+}
+
+// IsNull returns true if the literal is the "null" keyword.
+func (l Literal) IsNull() bool {
+	return l.Type == TypeUnknown && l.Value == "null"
+}
+
+// Interval is a duration literal such as "7days" or "3months".
+type Interval struct {
+	Position
+	Value string
+	Unit  string
+}
+
+// String returns the PRQL expression for this Interval.
+func (i Interval) String() string {
+	return i.Value + i.Unit
+}
+
+// Binary is an operation between two expressions, such as "a + b" or "a and b".
+type Binary struct {
+	Position
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// String returns the PRQL expression for this Binary operation.
+func (b Binary) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.Left.String(), b.Op, b.Right.String())
+}
+
+// Unary is an operation on a single expression, such as "-a" or "!a".
+type Unary struct {
+	Position
+	Op      string
+	Operand Expr
+}
+
+// String returns the PRQL expression for this Unary operation.
+func (u Unary) String() string {
+	return u.Op + u.Operand.String()
+}
+
+// Range is a range of values "start..end". Either bound may be nil when it is
+// left open.
+type Range struct {
+	Position
+	Start Expr
+	End   Expr
+}
+
+// String returns the PRQL expression for this Range.
+func (r Range) String() string {
+	var str strings.Builder
+	if r.Start != nil {
+		str.WriteString(r.Start.String())
+	}
+	str.WriteString("..")
+	if r.End != nil {
+		str.WriteString(r.End.String())
+	}
+	return str.String()
+}
+
+// List is a bracketed list of expressions "[a, b, c]".
+type List struct {
+	Position
+	Items []Expr
+}
+
+// String returns the PRQL expression for this List.
+func (l List) String() string {
+	items := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		items[i] = item.String()
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// Case chooses the value of the first arm who's condition is true
+// "case [cond => value, ...]".
+type Case struct {
+	Position
+	Arms []CaseArm
+}
+
+// CaseArm is a single "condition => value" arm of a Case.
+type CaseArm struct {
+	Condition Expr
+	Value     Expr
+}
+
+// String returns the PRQL expression for this Case.
+func (c Case) String() string {
+	arms := make([]string, len(c.Arms))
+	for i, arm := range c.Arms {
+		arms[i] = arm.Condition.String() + " => " + arm.Value.String()
+	}
+	return "case [" + strings.Join(arms, ", ") + "]"
+}
+
+// Assign gives an expression a name "name = expr".
+type Assign struct {
+	Position
+	Name  string
+	Value Expr
+}
+
+// String returns the PRQL expression for this Assign.
+func (a Assign) String() string {
+	return a.Name + " = " + a.Value.String()
+}
+
+// Call is a function or transform invocation "name {named:arg}... {arg}...".
+type Call struct {
+	Position
+	Name  string
+	Args  []Expr
+	Named map[string]Expr
+}
+
+// String returns the PRQL expression for this Call.
+func (c Call) String() string {
+	var str strings.Builder
+	str.WriteString(c.Name)
+
+	names := make([]string, 0, len(c.Named))
+	for name := range c.Named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		str.WriteString(" " + name + ":" + c.Named[name].String())
+	}
+	for _, arg := range c.Args {
+		str.WriteString(" " + arg.String())
+	}
+	return str.String()
+}
+
+// Pipeline is a series of stages joined by the pipe operator. When nested
+// within parenthesis, a pipeline may be used as an expression.
+type Pipeline struct {
+	Position
+	Stages []Expr
+}
+
+// String returns the PRQL expression for this Pipeline.
+func (p Pipeline) String() string {
+	stages := make([]string, len(p.Stages))
+	for i, stage := range p.Stages {
+		stages[i] = stage.String()
+	}
+	return "(" + strings.Join(stages, " | ") + ")"
+}
+
+// InterpolationPart is a single piece of an s-string or f-string. Either the
+// Text is used, or the Expr if it is not nil.
+type InterpolationPart struct {
+	Text string
+	Expr Expr
+}
+
+// Interpolation is an s-string (Kind 's') holding raw SQL, or an f-string
+// (Kind 'f') holding a formatted string. Expressions within curly-braces are
+// parsed into their own parts.
+type Interpolation struct {
+	Position
+	Kind  byte
+	Parts []InterpolationPart
+}
+
+// String returns the PRQL expression for this Interpolation.
+func (i Interpolation) String() string {
+	var str strings.Builder
+	str.WriteByte(i.Kind)
+	str.WriteByte('"')
+	for _, part := range i.Parts {
+		if part.Expr != nil {
+			str.WriteString("{" + part.Expr.String() + "}")
+		} else {
+			str.WriteString(part.Text)
+		}
+	}
+	str.WriteByte('"')
+	return str.String()
+}
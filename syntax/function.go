@@ -1,7 +1,27 @@
 package syntax
 
 // Function is a custom PRQL function for reusing functionality within a query.
+//
+// Syntax: "func {name} {named:default...} {positional...} -> {body}"
 type Function struct {
+	Position
+
 	// Name holds the given name of this symbol
 	Name string
+
+	// Named holds the optional parameters, in the order they were declared
+	Named []NamedParam
+
+	// Positional holds the names of the required parameters, in order
+	Positional []string
+
+	// Body is the expression the function is replaced with when called
+	Body Expr
+}
+
+// NamedParam is an optional parameter of a Function, used by name with a
+// default value.
+type NamedParam struct {
+	Name    string
+	Default Expr
 }
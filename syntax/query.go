@@ -0,0 +1,24 @@
+package syntax
+
+// Query is the root of a parsed PRQL document, holding the header directive
+// and the main pipeline.
+type Query struct {
+	Header Header
+
+	// Tables holds the named relations declared before the main pipeline
+	Tables []*Table
+
+	// Functions holds the functions declared before the main pipeline
+	Functions []*Function
+
+	// Main is the pipeline which produces the final result of the query
+	Main *Pipeline
+}
+
+// Table is a named relation declared by "let" or "table", which pipelines may
+// reference by it's name.
+type Table struct {
+	Position
+	Name     string
+	Pipeline *Pipeline
+}
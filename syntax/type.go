@@ -83,6 +83,7 @@ func (t *Type) UnmarshalText(text []byte) error {
 	str := string(text)
 	if typ, ok := typeTypeMap[str]; ok {
 		*t = typ
+		return nil
 	}
 
 	return fmt.Errorf("invalid Type '%s'", str)